@@ -0,0 +1,197 @@
+package omnilogger
+
+import (
+	"context"
+	"omnilogger/config"
+	"omnilogger/model"
+	pkg "omnilogger/pkg"
+	"sync"
+	"sync/atomic"
+)
+
+// DriverStats reports the async queue state of a single driver.
+type DriverStats struct {
+	Driver  pkg.LoggerDriver // The driver the queue belongs to.
+	Pending int              // Entries waiting in the queue.
+	Dropped uint64           // Entries discarded by the overflow policy.
+}
+
+// asyncItem is either a log entry or a flush marker the worker acknowledges once it reaches it.
+type asyncItem struct {
+	messageData model.MessageData
+	handler     ErrorHandler
+	flushed     chan struct{}
+}
+
+// asyncWorker owns the bounded queue and the long-lived goroutine of one driver.
+type asyncWorker struct {
+	driver   pkg.LoggerDriver
+	overflow config.OverflowPolicy
+	queue    chan asyncItem
+	done     chan struct{}
+	dropped  atomic.Uint64
+
+	mu     sync.RWMutex // Held for reading while enqueueing, for writing while closing.
+	closed bool
+
+	// Flush markers drop_oldest took off the queue. The worker may still be writing the entry
+	// before them, so they are acknowledged once it finishes its next item.
+	markersMu sync.Mutex
+	markers   []chan struct{}
+}
+
+func newAsyncWorker(driver pkg.LoggerDriver, cfg config.AsyncConfig) *asyncWorker {
+	w := &asyncWorker{
+		driver:   driver,
+		overflow: cfg.Overflow,
+		queue:    make(chan asyncItem, queueSize(cfg)),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func queueSize(cfg config.AsyncConfig) int {
+	if cfg.QueueSize <= 0 {
+		return config.DefaultQueueSize
+	}
+	return cfg.QueueSize
+}
+
+// matches reports whether the worker was built with the queue size and overflow policy of cfg.
+func (w *asyncWorker) matches(cfg config.AsyncConfig) bool {
+	return cap(w.queue) == queueSize(cfg) && w.overflow == cfg.Overflow
+}
+
+func (w *asyncWorker) run() {
+	defer close(w.done)
+	defer w.acknowledgeMarkers()
+	for item := range w.queue {
+		if item.flushed != nil {
+			close(item.flushed)
+		} else {
+			writeEntry(w.driver, item.messageData, item.handler)
+		}
+		w.acknowledgeMarkers()
+	}
+}
+
+// acknowledgeMarkers closes the flush markers taken off the queue before the item the worker just finished.
+func (w *asyncWorker) acknowledgeMarkers() {
+	w.markersMu.Lock()
+	markers := w.markers
+	w.markers = nil
+	w.markersMu.Unlock()
+	for _, flushed := range markers {
+		close(flushed)
+	}
+}
+
+// enqueue hands the entry to the worker according to the overflow policy.
+// It returns false once the worker is stopped, so the caller can write synchronously.
+func (w *asyncWorker) enqueue(messageData model.MessageData, handler ErrorHandler) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return false
+	}
+
+	item := asyncItem{messageData: messageData, handler: handler}
+	switch w.overflow {
+	case config.OverflowDropNewest:
+		select {
+		case w.queue <- item:
+		default:
+			w.dropped.Add(1)
+		}
+	case config.OverflowDropOldest:
+		for {
+			select {
+			case w.queue <- item:
+				return true
+			default:
+			}
+			// Make room by discarding the oldest entry, unless the worker got to it first.
+			// A flush marker is never discarded: the worker acknowledges it after its current write.
+			select {
+			case oldest := <-w.queue:
+				if oldest.flushed != nil {
+					w.markersMu.Lock()
+					w.markers = append(w.markers, oldest.flushed)
+					w.markersMu.Unlock()
+				} else {
+					w.dropped.Add(1)
+				}
+			default:
+			}
+		}
+	default:
+		w.queue <- item
+	}
+	return true
+}
+
+// flush waits until every entry queued before the call has been written.
+func (w *asyncWorker) flush(ctx context.Context) error {
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return nil
+	}
+	flushed := make(chan struct{})
+	select {
+	case w.queue <- asyncItem{flushed: flushed}:
+		w.mu.RUnlock()
+	case <-ctx.Done():
+		w.mu.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stop closes the queue and waits for the worker to drain it.
+func (w *asyncWorker) stop() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+	<-w.done
+}
+
+func (w *asyncWorker) stats() DriverStats {
+	return DriverStats{
+		Driver:  w.driver,
+		Pending: len(w.queue),
+		Dropped: w.dropped.Load(),
+	}
+}
+
+// Stats returns the queue state of every driver running in async mode.
+func (l *OmniLogger) Stats() []DriverStats {
+	var stats []DriverStats
+	for _, entry := range l.drivers.load() {
+		if entry.worker != nil {
+			stats = append(stats, entry.worker.stats())
+		}
+	}
+	return stats
+}
+
+// Dropped returns the total number of entries discarded by the async overflow policies.
+func (l *OmniLogger) Dropped() uint64 {
+	var dropped uint64
+	for _, entry := range l.drivers.load() {
+		if entry.worker != nil {
+			dropped += entry.worker.dropped.Load()
+		}
+	}
+	return dropped
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"omnilogger"
 	"omnilogger/config"
 	"omnilogger/model"
@@ -12,6 +13,9 @@ const (
 )
 
 func main() {
+	// Register the custom level below DEBUG
+	config.RegisterLevel(TRACE, 5)
+
 	// Setup log levels configuration
 	config := config.Config{
 		LogLevels: map[config.LogLevel]bool{
@@ -32,8 +36,11 @@ func main() {
 		panic("Failed to create driver file")
 	}
 
-	// initialize the singleton
-	omnilogger.AddDriver(cliDriver, fileDriver)
+	// Flush and close the drivers when main returns
+	defer omnilogger.Shutdown(context.Background())
+
+	// initialize the singleton, the file only persists WARN and above
+	omnilogger.AddDriver(cliDriver, omnilogger.WithDriverOptions(fileDriver, omnilogger.DriverOptions{MinLevel: omnilogger.WARN}))
 	omnilogger.AddConfig(config)
 
 	// Create a new OmniLogger with the CLI driver
@@ -5,13 +5,185 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"time"
 )
 
 // LogLevel represents the level of logging.
 type LogLevel string
 
+// OverflowPolicy decides what an async driver queue does when it is full.
+type OverflowPolicy string
+
+const (
+	OverflowBlock      OverflowPolicy = "block"       // Wait until the queue has room.
+	OverflowDropNewest OverflowPolicy = "drop_newest" // Discard the entry being logged.
+	OverflowDropOldest OverflowPolicy = "drop_oldest" // Discard the oldest queued entry to make room.
+)
+
+// DefaultQueueSize is the async queue capacity used when AsyncConfig.QueueSize is not set.
+const DefaultQueueSize = 1024
+
+// AsyncConfig controls the asynchronous logging pipeline.
+type AsyncConfig struct {
+	Enabled   bool           `json:"enabled"`    // Deliver entries through per-driver queues.
+	QueueSize int            `json:"queue_size"` // Capacity of each driver queue.
+	Overflow  OverflowPolicy `json:"overflow"`   // What to do when a queue is full, defaults to OverflowBlock.
+}
+
+// DefaultStackDepth is the number of frames captured when StackTraceConfig.Depth is not set.
+const DefaultStackDepth = 32
+
+// StackTraceConfig controls which entries carry a full stack trace. Without any setting,
+// ERROR and above capture one.
+type StackTraceConfig struct {
+	Disabled         bool              `json:"disabled"`          // Never capture full stack traces.
+	MinLevel         LogLevel          `json:"min_level"`         // Lowest level capturing a stack, ERROR when not set.
+	Levels           map[LogLevel]bool `json:"levels"`            // Explicit per-level overrides, checked before MinLevel.
+	Depth            int               `json:"depth"`             // Maximum number of frames, DefaultStackDepth when not set.
+	FilterRuntime    bool              `json:"filter_runtime"`    // Leave out frames of the Go runtime.
+	FilterOmnilogger bool              `json:"filter_omnilogger"` // Leave out frames of the omnilogger package itself.
+	GoroutineDump    bool              `json:"goroutine_dump"`    // Attach a dump of all goroutines to entries at terminal levels such as FATAL.
+}
+
+// MetadataOrder decides the order in which formatters write metadata fields.
+type MetadataOrder string
+
+const (
+	MetadataSorted    MetadataOrder = "sorted"    // Sorted by key, the default.
+	MetadataInsertion MetadataOrder = "insertion" // In the order the fields were added with With and WithFields.
+)
+
+// Names of the entry fields a FieldOrder can arrange. FieldMetadata stands for the whole metadata block.
+const (
+	FieldTimestamp     = "timestamp"
+	FieldLevel         = "level"
+	FieldMessage       = "message"
+	FieldCaller        = "caller"
+	FieldTransactionID = "transaction_id"
+	FieldUserID        = "user_id"
+	FieldMetadata      = "metadata"
+)
+
+// FieldOrder controls the order of the fields written by the built-in formatters.
+type FieldOrder struct {
+	// Fields lists entry fields in output order. Fields left out follow in the formatter's default
+	// order, and the metadata comes after the listed fields unless FieldMetadata is listed.
+	Fields   []string      `json:"fields"`
+	Metadata MetadataOrder `json:"metadata"` // Order of the metadata fields, MetadataSorted when not set.
+}
+
+// Validate reports unknown or repeated field names and unknown metadata orders.
+func (o FieldOrder) Validate() error {
+	seen := make(map[string]bool, len(o.Fields))
+	for _, field := range o.Fields {
+		switch field {
+		case FieldTimestamp, FieldLevel, FieldMessage, FieldCaller, FieldTransactionID, FieldUserID, FieldMetadata:
+		default:
+			return fmt.Errorf("unknown field in order: %q", field)
+		}
+		if seen[field] {
+			return fmt.Errorf("field listed twice in order: %q", field)
+		}
+		seen[field] = true
+	}
+	switch o.Metadata {
+	case "", MetadataSorted, MetadataInsertion:
+		return nil
+	default:
+		return fmt.Errorf("unknown metadata order: %q", o.Metadata)
+	}
+}
+
+// KeyCollision decides what the JSON formatter does with metadata keys that collide with the
+// fields it writes itself, such as level or timestamp.
+type KeyCollision string
+
+const (
+	KeyCollisionPrefix KeyCollision = "prefix" // Write colliding keys with a "fields." prefix, the default.
+	KeyCollisionNest   KeyCollision = "nest"   // Write all metadata under a "fields" object.
+	KeyCollisionError  KeyCollision = "error"  // Fail to format the entry, reporting the error to the error handler.
+)
+
+// FormatConfig describes the formatter built by drivers.NewFormatter.
+type FormatConfig struct {
+	Type    string     `json:"type"`    // "text", "json", "logfmt", "console" or "pattern", "pattern" when only Pattern is set.
+	Pattern string     `json:"pattern"` // Layout of the "pattern" type, such as "%time{2006-01-02} %level %msg %meta".
+	Order   FieldOrder `json:"order"`   // Field order; the console and pattern types only use its metadata order.
+
+	KeyCollision KeyCollision `json:"key_collision"` // Handling of reserved metadata keys by the "json" type, KeyCollisionPrefix when not set.
+}
+
+// DefaultFlushInterval is how long entries stay in a file buffer when FileConfig.FlushInterval is not set.
+const DefaultFlushInterval = time.Second
+
+// Durability decides how far a file driver pushes entries at its sync level before the logging call returns.
+type Durability string
+
+const (
+	DurabilityNone  Durability = "none"  // Leave them in the buffer until it fills or the flush interval passes.
+	DurabilityFlush Durability = "flush" // Hand them to the operating system.
+	DurabilityFsync Durability = "fsync" // Hand them to the operating system and sync the file to disk, the default.
+)
+
+// Duration is a time.Duration read from configuration files as a string such as "500ms" or "2s".
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// FileMode is a permission mode read from configuration files as an octal string such as "0640".
+type FileMode uint32
+
+func (m FileMode) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%#o", uint32(m))), nil
+}
+
+func (m *FileMode) UnmarshalText(text []byte) error {
+	parsed, err := strconv.ParseUint(string(text), 8, 32)
+	if err != nil {
+		return fmt.Errorf("invalid file mode %q: %w", text, err)
+	}
+	*m = FileMode(parsed)
+	return nil
+}
+
+// FileConfig describes a file driver built by drivers.NewFileDriverFromConfig.
+type FileConfig struct {
+	Path          string     `json:"path"`
+	BufferSize    int        `json:"buffer_size"`    // Bytes buffered in memory before a write, unbuffered when not set.
+	FlushInterval Duration   `json:"flush_interval"` // Longest time entries stay buffered, DefaultFlushInterval when not set.
+	Durability    Durability `json:"durability"`     // What happens to entries at SyncLevel and above, DurabilityFsync when not set.
+	SyncLevel     LogLevel   `json:"sync_level"`     // Lowest level flushed and synced at once, ERROR when not set.
+
+	FileMode       FileMode `json:"file_mode"`        // Permissions of a created file, such as "0640".
+	DirMode        FileMode `json:"dir_mode"`         // Permissions of created parent directories, such as "0750".
+	CheckInterval  Duration `json:"check_interval"`   // How often writes check whether the file was moved or deleted.
+	ReopenOnSIGHUP bool     `json:"reopen_on_sighup"` // Reopen the file on SIGHUP, after an external rotation.
+}
+
 type Config struct {
-	LogLevels map[LogLevel]bool `json:"log_levels"`
+	LogLevels map[LogLevel]bool `json:"log_levels"` // Explicit per-level overrides.
+	MinLevel  LogLevel          `json:"min_level"`  // Lowest severity enabled when a level has no override.
+	Async     AsyncConfig       `json:"async"`
+
+	TerminalLevels map[LogLevel]bool `json:"terminal_levels"` // Levels that end the process once logged. FATAL is terminal unless set to false.
+	ExitCode       *int              `json:"exit_code"`       // Exit code used by terminal levels, 1 when not set.
+
+	StackTrace StackTraceConfig `json:"stack_trace"`
+	Format     FormatConfig     `json:"format"`
+
+	Files map[string]FileConfig `json:"files"` // File drivers by name.
 }
 
 // LoadConfig loads the configuration from a JSON file
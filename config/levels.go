@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Severity orders log levels, higher values are more severe.
+type Severity int
+
+const (
+	DEBUG LogLevel = "DEBUG" // Debug level logging.
+	INFO  LogLevel = "INFO"  // Information level logging.
+	WARN  LogLevel = "WARN"  // Warning level logging.
+	ERROR LogLevel = "ERROR" // Error level logging.
+	FATAL LogLevel = "FATAL" // Fatal level logging, which causes application termination.
+)
+
+var (
+	severitiesMu sync.RWMutex
+	severities   = map[LogLevel]Severity{
+		DEBUG: 10,
+		INFO:  20,
+		WARN:  30,
+		ERROR: 40,
+		FATAL: 50,
+	}
+)
+
+// Normalize returns the canonical upper-case form of the level name.
+func (l LogLevel) Normalize() LogLevel {
+	return LogLevel(strings.ToUpper(strings.TrimSpace(string(l))))
+}
+
+// UnmarshalText normalizes level names read from configuration files, so "warn" and "WARN" are the same level.
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	*l = LogLevel(text).Normalize()
+	return nil
+}
+
+// RegisterLevel registers a custom level at the given severity, or changes the severity of an existing one.
+// The built-in levels use 10 (DEBUG) to 50 (FATAL), so a TRACE level would typically be registered at 5.
+func RegisterLevel(level LogLevel, severity Severity) LogLevel {
+	level = level.Normalize()
+	severitiesMu.Lock()
+	defer severitiesMu.Unlock()
+	severities[level] = severity
+	return level
+}
+
+// SeverityOf returns the severity of a registered level.
+func SeverityOf(level LogLevel) (Severity, bool) {
+	severitiesMu.RLock()
+	defer severitiesMu.RUnlock()
+	severity, ok := severities[level.Normalize()]
+	return severity, ok
+}
+
+// ParseLevel returns the registered level matching name, ignoring case.
+func ParseLevel(name string) (LogLevel, error) {
+	level := LogLevel(name).Normalize()
+	if _, ok := SeverityOf(level); !ok {
+		return "", fmt.Errorf("unknown log level: %q", name)
+	}
+	return level, nil
+}
+
+// Enabled reports whether level passes the configuration. An entry in LogLevels always wins,
+// otherwise the level is enabled when its severity is at or above MinLevel.
+func (c Config) Enabled(level LogLevel) bool {
+	if enabled, ok := c.LogLevels[level]; ok {
+		return enabled
+	}
+	if enabled, ok := c.LogLevels[level.Normalize()]; ok {
+		return enabled
+	}
+	if c.MinLevel == "" {
+		return false
+	}
+	minSeverity, ok := SeverityOf(c.MinLevel)
+	if !ok {
+		return false
+	}
+	severity, ok := SeverityOf(level)
+	return ok && severity >= minSeverity
+}
+
+// IsTerminal reports whether logging at level ends the process. FATAL always does unless
+// TerminalLevels explicitly sets it to false, as callers of Fatal rely on it never returning.
+func (c Config) IsTerminal(level LogLevel) bool {
+	if terminal, ok := c.TerminalLevels[level]; ok {
+		return terminal
+	}
+	if terminal, ok := c.TerminalLevels[level.Normalize()]; ok {
+		return terminal
+	}
+	return level.Normalize() == FATAL
+}
+
+// TerminalExitCode returns the exit code used after a terminal level is logged, 1 when ExitCode is not set.
+func (c Config) TerminalExitCode() int {
+	if c.ExitCode == nil {
+		return 1
+	}
+	return *c.ExitCode
+}
+
+// Captures reports whether entries at level carry a full stack trace.
+func (c StackTraceConfig) Captures(level LogLevel) bool {
+	if c.Disabled {
+		return false
+	}
+	minLevel := c.MinLevel
+	if minLevel == "" {
+		minLevel = ERROR
+	}
+	return Config{MinLevel: minLevel, LogLevels: c.Levels}.Enabled(level)
+}
+
+// MaxDepth returns the maximum number of frames to capture.
+func (c StackTraceConfig) MaxDepth() int {
+	if c.Depth <= 0 {
+		return DefaultStackDepth
+	}
+	return c.Depth
+}
@@ -0,0 +1,37 @@
+package omnilogger
+
+import (
+	"omnilogger/config"
+	pkg "omnilogger/pkg"
+)
+
+// DriverOptions holds the settings the logger applies to a single driver.
+type DriverOptions struct {
+	Name      string                   // Identifies the driver for RemoveDriver, optional.
+	MinLevel  config.LogLevel          // Lowest severity the driver receives, empty to receive every enabled level.
+	LogLevels map[config.LogLevel]bool // Explicit per-level overrides, checked before MinLevel.
+}
+
+// configuredDriver carries DriverOptions from WithDriverOptions to the logger.
+type configuredDriver struct {
+	pkg.LoggerDriver
+	options DriverOptions
+}
+
+// WithDriverOptions attaches options to a driver before it is passed to NewOmniLogger or AddDriver.
+//
+//	fileDriver := omnilogger.WithDriverOptions(file, omnilogger.DriverOptions{MinLevel: omnilogger.WARN})
+func WithDriverOptions(driver pkg.LoggerDriver, options DriverOptions) pkg.LoggerDriver {
+	return &configuredDriver{LoggerDriver: driver, options: options}
+}
+
+// enabled reports whether the driver accepts entries of the given level.
+func (o DriverOptions) enabled(level config.LogLevel) bool {
+	if enabled, ok := o.LogLevels[level]; ok {
+		return enabled
+	}
+	if o.MinLevel == "" {
+		return true
+	}
+	return config.Config{MinLevel: o.MinLevel}.Enabled(level)
+}
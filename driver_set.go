@@ -0,0 +1,127 @@
+package omnilogger
+
+import (
+	"omnilogger/config"
+	pkg "omnilogger/pkg"
+	"sync"
+	"sync/atomic"
+)
+
+// driverEntry pairs a driver with the delivery state the logger keeps for it.
+type driverEntry struct {
+	driver       pkg.LoggerDriver
+	options      DriverOptions
+	worker       *asyncWorker // Set while the driver runs in async mode.
+	shutdownOnce sync.Once
+}
+
+func newDriverEntries(async config.AsyncConfig, drivers []pkg.LoggerDriver) []*driverEntry {
+	entries := make([]*driverEntry, 0, len(drivers))
+	for _, driver := range drivers {
+		entry := &driverEntry{driver: driver}
+		if configured, ok := driver.(*configuredDriver); ok {
+			entry.driver = configured.LoggerDriver
+			entry.options = configured.options
+		}
+		if async.Enabled {
+			entry.worker = newAsyncWorker(entry.driver, async)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// driverSet holds the drivers shared by a logger and its children. Logging reads an
+// immutable snapshot of the entries, changes publish a new snapshot under mu.
+type driverSet struct {
+	mu      sync.Mutex
+	entries atomic.Pointer[[]*driverEntry]
+}
+
+func newDriverSet(entries []*driverEntry) *driverSet {
+	set := &driverSet{}
+	set.entries.Store(&entries)
+	return set
+}
+
+// load returns the current snapshot, callers must not modify it.
+func (s *driverSet) load() []*driverEntry {
+	return *s.entries.Load()
+}
+
+// update publishes the entries returned by fn, which receives the current snapshot and must not modify it.
+// Workers of entries left out of the new snapshot are drained once it is published.
+func (s *driverSet) update(fn func(entries []*driverEntry) []*driverEntry) {
+	s.mu.Lock()
+	previous := s.load()
+	next := fn(previous)
+	s.entries.Store(&next)
+	s.mu.Unlock()
+
+	kept := make(map[*driverEntry]bool, len(next))
+	for _, entry := range next {
+		kept[entry] = true
+	}
+	for _, entry := range previous {
+		if !kept[entry] && entry.worker != nil {
+			entry.worker.stop()
+		}
+	}
+}
+
+// applyAsync returns entries with workers started, stopped or rebuilt to match the async configuration.
+// A worker with another queue size or overflow policy is replaced, keeping its count of dropped entries.
+func applyAsync(entries []*driverEntry, async config.AsyncConfig) []*driverEntry {
+	next := make([]*driverEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !async.Enabled && entry.worker == nil || async.Enabled && entry.worker != nil && entry.worker.matches(async) {
+			next = append(next, entry)
+			continue
+		}
+		replacement := &driverEntry{driver: entry.driver, options: entry.options}
+		if async.Enabled {
+			replacement.worker = newAsyncWorker(entry.driver, async)
+			if entry.worker != nil {
+				replacement.worker.dropped.Store(entry.worker.dropped.Load())
+			}
+		}
+		next = append(next, replacement)
+	}
+	return next
+}
+
+// AddDriver appends one or more drivers to the logger. Drivers are shared with the logger's
+// children, and the change is safe while other goroutines are logging.
+func (l *OmniLogger) AddDriver(drivers ...pkg.LoggerDriver) {
+	l.drivers.update(func(entries []*driverEntry) []*driverEntry {
+		// SetConfig publishes settings under the same lock, so the async mode read here is current.
+		added := newDriverEntries(l.load().config.Async, drivers)
+		return append(append([]*driverEntry(nil), entries...), added...)
+	})
+}
+
+// RemoveDriver removes the drivers registered under name with DriverOptions.Name and reports
+// whether any was found. Their pending async entries are written first, the drivers are not closed.
+func (l *OmniLogger) RemoveDriver(name string) bool {
+	removed := false
+	l.drivers.update(func(entries []*driverEntry) []*driverEntry {
+		next := make([]*driverEntry, 0, len(entries))
+		for _, entry := range entries {
+			if entry.options.Name == name {
+				removed = true
+				continue
+			}
+			next = append(next, entry)
+		}
+		return next
+	})
+	return removed
+}
+
+// ReplaceDrivers swaps all drivers of the logger at once. Pending async entries of the
+// previous drivers are written first, the previous drivers are not closed.
+func (l *OmniLogger) ReplaceDrivers(drivers ...pkg.LoggerDriver) {
+	l.drivers.update(func([]*driverEntry) []*driverEntry {
+		return newDriverEntries(l.load().config.Async, drivers)
+	})
+}
@@ -0,0 +1,109 @@
+package omnilogger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"omnilogger/model"
+	pkg "omnilogger/pkg"
+	"os"
+	"sync/atomic"
+)
+
+const (
+	OpFormat = "format" // The driver failed to format the entry.
+	OpWrite  = "write"  // The driver failed to write the entry.
+)
+
+// DriverError is the error passed to an ErrorHandler, it records which driver operation failed.
+type DriverError struct {
+	Op  string // OpFormat or OpWrite.
+	Err error  // The error returned by the driver.
+}
+
+func (e *DriverError) Error() string {
+	return fmt.Sprintf("%s log: %v", e.Op, e.Err)
+}
+
+func (e *DriverError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorHandler receives the errors drivers return while formatting or writing an entry.
+// Handlers may be called concurrently from several drivers.
+type ErrorHandler interface {
+	HandleError(driver pkg.LoggerDriver, messageData model.MessageData, err error)
+}
+
+// ErrorHandlerFunc adapts a function to the ErrorHandler interface.
+type ErrorHandlerFunc func(driver pkg.LoggerDriver, messageData model.MessageData, err error)
+
+func (f ErrorHandlerFunc) HandleError(driver pkg.LoggerDriver, messageData model.MessageData, err error) {
+	f(driver, messageData, err)
+}
+
+// IgnoreErrors discards every driver error.
+var IgnoreErrors ErrorHandler = ErrorHandlerFunc(func(pkg.LoggerDriver, model.MessageData, error) {})
+
+// StderrErrorHandler reports driver errors on standard error. It is the default handler.
+var StderrErrorHandler ErrorHandler = NewWriterErrorHandler(os.Stderr)
+
+// NewWriterErrorHandler returns a handler that reports driver errors on w.
+func NewWriterErrorHandler(w io.Writer) ErrorHandler {
+	return ErrorHandlerFunc(func(driver pkg.LoggerDriver, messageData model.MessageData, err error) {
+		fmt.Fprintf(w, "omnilogger: %T: %v (entry: [%s] %s)\n", driver, err, messageData.Level, messageData.Message)
+	})
+}
+
+// FallbackErrorHandler writes entries a driver failed on with the fallback driver instead.
+// Errors of the fallback driver itself are reported on standard error.
+func FallbackErrorHandler(fallback pkg.LoggerDriver) ErrorHandler {
+	return ErrorHandlerFunc(func(driver pkg.LoggerDriver, messageData model.MessageData, err error) {
+		writeEntry(fallback, messageData, StderrErrorHandler)
+	})
+}
+
+// MetricsErrorHandler counts driver errors by operation and passes them on to Next, if set.
+type MetricsErrorHandler struct {
+	Next ErrorHandler
+
+	formatErrors atomic.Uint64
+	writeErrors  atomic.Uint64
+}
+
+func (m *MetricsErrorHandler) HandleError(driver pkg.LoggerDriver, messageData model.MessageData, err error) {
+	var driverErr *DriverError
+	if errors.As(err, &driverErr) && driverErr.Op == OpFormat {
+		m.formatErrors.Add(1)
+	} else {
+		m.writeErrors.Add(1)
+	}
+	if m.Next != nil {
+		m.Next.HandleError(driver, messageData, err)
+	}
+}
+
+// FormatErrors returns the number of entries drivers failed to format.
+func (m *MetricsErrorHandler) FormatErrors() uint64 {
+	return m.formatErrors.Load()
+}
+
+// WriteErrors returns the number of entries drivers failed to write.
+func (m *MetricsErrorHandler) WriteErrors() uint64 {
+	return m.writeErrors.Load()
+}
+
+// SetErrorHandler replaces the handler receiving driver errors, nil restores StderrErrorHandler.
+// Child loggers created afterwards inherit the handler.
+func (l *OmniLogger) SetErrorHandler(handler ErrorHandler) {
+	l.updateSettings(func(settings *loggerSettings) {
+		settings.errorHandler = handler
+	})
+}
+
+func (s *loggerSettings) getErrorHandler() ErrorHandler {
+	if s.errorHandler == nil {
+		return StderrErrorHandler
+	}
+	return s.errorHandler
+}
@@ -0,0 +1,44 @@
+package omnilogger
+
+import (
+	"omnilogger/model"
+)
+
+// With returns a child logger that adds a single metadata field to every entry.
+func (l *OmniLogger) With(key string, value interface{}) *OmniLogger {
+	return l.WithFields(map[string]interface{}{key: value})
+}
+
+// WithFields returns a child logger that adds the given metadata fields to every entry.
+// The parent logger and its context are left unchanged.
+func (l *OmniLogger) WithFields(fields map[string]interface{}) *OmniLogger {
+	return l.WithContext(model.Context{MetaData: fields})
+}
+
+// WithContext returns a child logger whose context is the parent's context merged with ctx.
+// Non-empty TransactionID and UserID values replace the parent's, MetaData keys are added or replaced.
+func (l *OmniLogger) WithContext(ctx model.Context) *OmniLogger {
+	return l.child(l.context.Merge(&ctx))
+}
+
+// Err returns a child logger that attaches err to every entry. Drivers render its
+// wrapped chain, errors.Join branches, concrete type and any stack trace it carries.
+//
+//	logger.Err(err).Error("could not save order")
+func (l *OmniLogger) Err(err error) *OmniLogger {
+	child := l.child(l.context)
+	child.err = err
+	return child
+}
+
+// child returns a logger sharing the settings and drivers of l with a different context.
+func (l *OmniLogger) child(ctx *model.Context) *OmniLogger {
+	child := &OmniLogger{
+		context:    ctx,
+		drivers:    l.drivers,
+		callerSkip: l.callerSkip,
+		err:        l.err,
+	}
+	child.settings.Store(l.load())
+	return child
+}
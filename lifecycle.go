@@ -0,0 +1,104 @@
+package omnilogger
+
+import (
+	"context"
+	"errors"
+	pkg "omnilogger/pkg"
+	"os"
+	"time"
+)
+
+// fatalShutdownTimeout bounds the shutdown that runs before a terminal level ends the process.
+const fatalShutdownTimeout = 5 * time.Second
+
+// Flush writes out the entries waiting in the async queues and flushes every driver that buffers output.
+func (l *OmniLogger) Flush(ctx context.Context) error {
+	return runUntilDone(ctx, func() error {
+		var errs []error
+		for _, entry := range l.drivers.load() {
+			if entry.worker != nil {
+				if err := entry.worker.flush(ctx); err != nil {
+					return err
+				}
+			}
+			if flusher, ok := entry.driver.(pkg.Flusher); ok {
+				errs = append(errs, flusher.Flush())
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// Shutdown drains the async queues, then flushes and closes every driver. It gives up and
+// returns the context error once ctx is done. Loggers sharing the drivers must not be used afterwards.
+func (l *OmniLogger) Shutdown(ctx context.Context) error {
+	return runUntilDone(ctx, func() error {
+		var errs []error
+		for _, entry := range l.drivers.load() {
+			errs = append(errs, entry.shutdown())
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// shutdown stops the worker and releases the driver, only the first call has an effect.
+func (e *driverEntry) shutdown() error {
+	var err error
+	e.shutdownOnce.Do(func() {
+		if e.worker != nil {
+			e.worker.stop()
+		}
+		var errs []error
+		if flusher, ok := e.driver.(pkg.Flusher); ok {
+			errs = append(errs, flusher.Flush())
+		}
+		if closer, ok := e.driver.(pkg.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+		err = errors.Join(errs...)
+	})
+	return err
+}
+
+// runUntilDone runs fn in the background and waits for it or for ctx, whichever finishes first.
+func runUntilDone(ctx context.Context, fn func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetExitFunc replaces the function called after a terminal level is logged, for example
+// to panic or to record the exit code in tests. Passing nil restores os.Exit.
+// Child loggers created afterwards inherit the function.
+//
+// With a replaced function the drivers are only flushed before it is called, so the logger
+// stays usable if it returns. A function that ends the process should call Shutdown first.
+func (l *OmniLogger) SetExitFunc(exit func(code int)) {
+	l.updateSettings(func(settings *loggerSettings) {
+		settings.exitFunc = exit
+	})
+}
+
+// exit shuts the drivers down within a bounded amount of time, then ends the process. A replaced
+// exit function may return, so the drivers are only flushed before calling it.
+func (l *OmniLogger) exit() {
+	settings := l.load()
+	ctx, cancel := context.WithTimeout(context.Background(), fatalShutdownTimeout)
+	defer cancel()
+
+	if settings.exitFunc != nil {
+		l.Flush(ctx)
+		settings.exitFunc(settings.config.TerminalExitCode())
+		return
+	}
+	l.Shutdown(ctx)
+	os.Exit(settings.config.TerminalExitCode())
+}
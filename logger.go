@@ -1,110 +1,154 @@
 package omnilogger
 
 import (
+	"context"
+	"fmt"
 	"omnilogger/config"
 	"omnilogger/model"
 	pkg "omnilogger/pkg"
+	"sync/atomic"
 )
 
 var (
-	instance *OmniLogger // Singleton instance of OmniLogger.
+	defaultLogger atomic.Pointer[OmniLogger] // Singleton instance of OmniLogger.
 )
 
 // NewOmniLogger creates and returns a new logger instance with the given configuration, context, and drivers.
 func NewOmniLogger(config config.Config, ctx *model.Context, drivers ...pkg.LoggerDriver) *OmniLogger {
-	return &OmniLogger{
-		config:  config,
-		drivers: drivers,
+	logger := &OmniLogger{
 		context: ctx,
+		drivers: newDriverSet(newDriverEntries(config.Async, drivers)),
+	}
+	logger.settings.Store(&loggerSettings{config: config})
+	return logger
+}
+
+// Default returns the singleton logger used by the package-level functions.
+func Default() *OmniLogger {
+	if logger := defaultLogger.Load(); logger != nil {
+		return logger
+	}
+	defaultLogger.CompareAndSwap(nil, NewOmniLogger(config.Config{}, nil))
+	return defaultLogger.Load()
+}
+
+// SetDefault makes logger the singleton and returns a function that restores the previous one,
+// which keeps tests from leaking their logger:
+//
+//	defer omnilogger.SetDefault(testLogger)()
+func SetDefault(logger *OmniLogger) (restore func()) {
+	previous := defaultLogger.Swap(logger)
+	return func() {
+		defaultLogger.Store(previous)
 	}
 }
 
 // AddConfig updates the configuration of the singleton logger instance.
 func AddConfig(config config.Config) {
-	ensureInstance()
-	instance.config = config
+	Default().SetConfig(config)
 }
 
 // AddDriver appends one or more logging drivers to the singleton logger instance.
 func AddDriver(drivers ...pkg.LoggerDriver) {
-	ensureInstance()
-	instance.drivers = append(instance.drivers, drivers...)
+	Default().AddDriver(drivers...)
+}
+
+// RemoveDriver removes the drivers registered under name from the singleton logger instance.
+func RemoveDriver(name string) bool {
+	return Default().RemoveDriver(name)
+}
+
+// ReplaceDrivers swaps all drivers of the singleton logger instance.
+func ReplaceDrivers(drivers ...pkg.LoggerDriver) {
+	Default().ReplaceDrivers(drivers...)
 }
 
 // GetOmniLoggerWithContext retrieves a copy of the global logger instance with a specified context.
 func GetOmniLoggerWithContext(ctx model.Context) (*OmniLogger, error) {
-	ensureInstance()
-	return &OmniLogger{
-		config:  instance.config,
-		drivers: instance.drivers,
-		context: &ctx,
-	}, nil
+	return Default().child(&ctx), nil
 }
 
-func ensureInstance() {
-	if instance == nil {
-		instance = &OmniLogger{}
-	}
+// With returns a child of the singleton logger with a single metadata field.
+func With(key string, value interface{}) *OmniLogger {
+	return Default().With(key, value)
+}
+
+// WithFields returns a child of the singleton logger with the given metadata fields.
+func WithFields(fields map[string]interface{}) *OmniLogger {
+	return Default().WithFields(fields)
+}
+
+// Err returns a child of the singleton logger that attaches err to every entry.
+func Err(err error) *OmniLogger {
+	return Default().Err(err)
+}
+
+// SetExitFunc replaces the function the singleton logger calls after a terminal level is logged.
+func SetExitFunc(exit func(code int)) {
+	Default().SetExitFunc(exit)
+}
+
+// SetErrorHandler replaces the handler receiving driver errors of the singleton logger.
+func SetErrorHandler(handler ErrorHandler) {
+	Default().SetErrorHandler(handler)
+}
+
+// Flush writes out pending entries of the singleton logger.
+func Flush(ctx context.Context) error {
+	return Default().Flush(ctx)
+}
+
+// Shutdown drains, flushes and closes the drivers of the singleton logger.
+func Shutdown(ctx context.Context) error {
+	return Default().Shutdown(ctx)
 }
 
 func Warnf(format string, args ...interface{}) {
-	ensureInstance()
-	instance.Warnf(format, args...)
+	Default().log(WARN, fmt.Sprintf(format, args...))
 }
 
 // Logf formats and logs a message at a specified log level ussualy used for custome logs
 func Logf(level config.LogLevel, format string, args ...interface{}) {
-	ensureInstance()
-	instance.Logf(level, format, args...)
+	Default().log(level, fmt.Sprintf(format, args...))
 }
 
 func Debugf(format string, args ...interface{}) {
-	ensureInstance()
-	instance.Debugf(format, args...)
+	Default().log(DEBUG, fmt.Sprintf(format, args...))
 }
 
 func Infof(format string, args ...interface{}) {
-	ensureInstance()
-	instance.Infof(format, args...)
+	Default().log(INFO, fmt.Sprintf(format, args...))
 }
 
 func Errorf(format string, args ...interface{}) {
-	ensureInstance()
-	instance.Errorf(format, args...)
+	Default().log(ERROR, fmt.Sprintf(format, args...))
 }
 
 func Fatalf(format string, args ...interface{}) {
-	ensureInstance()
-	instance.Fatalf(format, args...)
+	Default().log(FATAL, fmt.Sprintf(format, args...))
 }
 
 // Log logs a message at a specified log level usually used for custome logs
 func Log(level config.LogLevel, message string) {
-	ensureInstance()
-	instance.Log(level, message)
+	Default().log(level, message)
 }
 
 func Debug(message string) {
-	ensureInstance()
-	instance.Debug(message)
+	Default().log(DEBUG, message)
 }
 
 func Info(message string) {
-	ensureInstance()
-	instance.Info(message)
+	Default().log(INFO, message)
 }
 
 func Warn(message string) {
-	ensureInstance()
-	instance.Warn(message)
+	Default().log(WARN, message)
 }
 
 func Error(message string) {
-	ensureInstance()
-	instance.Error(message)
+	Default().log(ERROR, message)
 }
 
 func Fatal(message string) {
-	ensureInstance()
-	instance.Fatal(message)
+	Default().log(FATAL, message)
 }
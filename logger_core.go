@@ -5,136 +5,250 @@ import (
 	"omnilogger/config"
 	"omnilogger/model"
 	pkg "omnilogger/pkg"
-	"os"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
-	DEBUG config.LogLevel = "DEBUG" // Debug level logging.
-	INFO  config.LogLevel = "INFO"  // Information level logging.
-	WARN  config.LogLevel = "WARN"  // Warning level logging.
-	ERROR config.LogLevel = "ERROR" // Error level logging.
-	FATAL config.LogLevel = "FATAL" // Fatal level logging, which causes application termination.
+	DEBUG = config.DEBUG // Debug level logging.
+	INFO  = config.INFO  // Information level logging.
+	WARN  = config.WARN  // Warning level logging.
+	ERROR = config.ERROR // Error level logging.
+	FATAL = config.FATAL // Fatal level logging, which causes application termination.
 )
 
 // OmniLogger is the main structure for the logger, holding configuration, context, and drivers.
 type OmniLogger struct {
-	config  config.Config      // Logger configuration.
-	context *model.Context     // Context information for logging.
-	drivers []pkg.LoggerDriver // List of logging drivers.
+	settings atomic.Pointer[loggerSettings] // Configuration and hooks, replaced as a whole on change.
+	context  *model.Context                 // Context information for logging.
+	drivers  *driverSet                     // Logging drivers, shared with child loggers.
+	// callerSkip is the number of extra frames between the caller and the logging methods.
+	callerSkip int
+	err        error // Attached to every entry, set with Err.
+}
+
+// loggerSettings holds the configuration and hooks of a logger. A published value is never
+// modified, setters store an updated copy so they are safe while other goroutines are logging.
+type loggerSettings struct {
+	config       config.Config  // Logger configuration.
+	exitFunc     func(code int) // Called after a terminal level is logged, os.Exit when nil.
+	errorHandler ErrorHandler   // Receives driver errors, StderrErrorHandler when nil.
+}
+
+// load returns the current settings, callers must not modify them.
+func (l *OmniLogger) load() *loggerSettings {
+	return l.settings.Load()
+}
+
+// updateSettings publishes a copy of the current settings modified by fn.
+func (l *OmniLogger) updateSettings(fn func(settings *loggerSettings)) {
+	for {
+		current := l.settings.Load()
+		next := *current
+		fn(&next)
+		if l.settings.CompareAndSwap(current, &next) {
+			return
+		}
+	}
+}
+
+// SetConfig replaces the configuration of the logger. Child loggers keep the configuration
+// they were created with, the async setting applies to the shared drivers.
+func (l *OmniLogger) SetConfig(config config.Config) {
+	// The settings are published under the driver lock, so drivers added meanwhile either get
+	// the new async mode or are converted along with the others.
+	l.drivers.update(func(entries []*driverEntry) []*driverEntry {
+		l.updateSettings(func(settings *loggerSettings) {
+			settings.config = config
+		})
+		return applyAsync(entries, config.Async)
+	})
+}
+
+// callerPC returns the program counter skip frames above the function calling callerPC,
+// so callerPC(0) identifies that function itself.
+func callerPC(skip int) uintptr {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return 0
+	}
+	return pcs[0]
 }
 
-// captureStackTrace captures the stack trace of the caller.
-func (l *OmniLogger) captureStackTrace() string {
-	pc, file, line, ok := runtime.Caller(4) // Skip 4 frames to get the caller's caller.
-	if !ok {
-		return "unknown"
+// frameForPC resolves a program counter captured by runtime.Callers.
+func frameForPC(pc uintptr) model.Frame {
+	if pc == 0 {
+		return model.Frame{}
 	}
-	funcName := runtime.FuncForPC(pc).Name()
-	return fmt.Sprintf("%s:%d %s", file, line, funcName)
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return model.Frame{Function: frame.Function, File: frame.File, Line: frame.Line}
 }
 
-// logWritter writes a log message to all configured drivers.
-func (l *OmniLogger) logWritter(level config.LogLevel, message string) {
-	if enabled, ok := l.config.LogLevels[level]; !ok || !enabled {
+// logWritter writes a log message to all configured drivers. pc identifies the code that logged it
+// and at the time it was logged, the current time when zero.
+func (l *OmniLogger) logWritter(level config.LogLevel, message string, pc uintptr, at time.Time) {
+	settings := l.load()
+	if !settings.config.Enabled(level) {
 		return
 	}
 
-	stack := l.captureStackTrace()
-	timestamp := time.Now().Format(time.RFC3339)
+	if at.IsZero() {
+		at = time.Now()
+	}
+	timestamp := at.Format(time.RFC3339)
 	messageData := model.MessageData{
-		Level:      l.levelToString(level),
-		Message:    message,
-		StackTrace: stack,
-		Context:    l.context,
-		Timestamp:  timestamp,
+		Level:     l.levelToString(level),
+		Message:   message,
+		Caller:    frameForPC(pc),
+		Error:     l.err,
+		Context:   l.context,
+		Timestamp: timestamp,
+	}
+	if stackConfig := settings.config.StackTrace; stackConfig.Captures(level) {
+		messageData.StackTrace = captureStack(stackConfig, pc, 1)
+	}
+	if settings.config.StackTrace.GoroutineDump && settings.config.IsTerminal(level) {
+		messageData.Goroutines = goroutineDump()
 	}
 
+	handler := settings.getErrorHandler()
 	var wg sync.WaitGroup
 
-	// Write log messages concurrently to all drivers.
-	for _, driver := range l.drivers {
+	// Write log messages concurrently to all drivers, or hand them to the driver queues in async mode.
+	for _, entry := range l.drivers.load() {
+		if !entry.options.enabled(level) {
+			continue // Skip formatting entirely for drivers that filter the level out.
+		}
+		if entry.worker != nil && entry.worker.enqueue(messageData, handler) {
+			continue
+		}
+
 		wg.Add(1)
 
 		go func(driver pkg.LoggerDriver) {
 			defer wg.Done()
-			formattedMessage, err := driver.FormatLog(messageData)
-			if err != nil {
-				fmt.Println("Error formatting log:", err)
-			}
-			err = driver.WriteLog(formattedMessage)
-			if err != nil {
-				fmt.Println("Error writing log:", err)
-			}
-		}(driver)
+			writeEntry(driver, messageData, handler)
+		}(entry.driver)
 	}
 
 	wg.Wait() // Wait for all log writes to complete.
 }
 
+// writeEntry formats and writes a single entry with the given driver, errors go to handler.
+// An entry that fails to format is not written.
+func writeEntry(driver pkg.LoggerDriver, messageData model.MessageData, handler ErrorHandler) {
+	if entryWriter, ok := driver.(pkg.EntryWriter); ok {
+		if err := entryWriter.WriteEntry(messageData); err != nil {
+			handler.HandleError(driver, messageData, &DriverError{Op: OpWrite, Err: err})
+		}
+		return
+	}
+
+	formattedMessage, err := driver.FormatLog(messageData)
+	if err != nil {
+		handler.HandleError(driver, messageData, &DriverError{Op: OpFormat, Err: err})
+		return
+	}
+	if levelWriter, ok := driver.(pkg.LevelWriter); ok {
+		err = levelWriter.WriteLevel(messageData.Level, formattedMessage)
+	} else {
+		err = driver.WriteLog(formattedMessage)
+	}
+	if err != nil {
+		handler.HandleError(driver, messageData, &DriverError{Op: OpWrite, Err: err})
+	}
+}
+
+// log writes the entry and ends the process when the level is terminal. It must be called
+// directly by the exported logging methods and package-level functions, so the caller
+// is always the same number of frames away.
+func (l *OmniLogger) log(level config.LogLevel, message string) {
+	settings := l.load()
+	if settings.config.Enabled(level) {
+		l.logWritter(level, message, callerPC(2+l.callerSkip), time.Time{})
+	}
+	if settings.config.IsTerminal(level) {
+		l.exit()
+	}
+}
+
+// AddCallerSkip returns a child logger that reports the caller n frames further up the stack.
+// Wrapper libraries use it so entries point at their callers instead of the wrapper.
+func (l *OmniLogger) AddCallerSkip(n int) *OmniLogger {
+	child := l.child(l.context)
+	child.callerSkip += n
+	return child
+}
+
 func (l *OmniLogger) levelToString(level config.LogLevel) string {
 	return string(level)
 }
 
+// AddCustomLogLevel enables or disables a level on this logger only, the configuration
+// of its parent and of other loggers is left unchanged.
 func (l *OmniLogger) AddCustomLogLevel(level config.LogLevel, enabled bool) {
-	l.config.LogLevels[level] = enabled
+	l.updateSettings(func(settings *loggerSettings) {
+		logLevels := make(map[config.LogLevel]bool, len(settings.config.LogLevels)+1)
+		for existing, existingEnabled := range settings.config.LogLevels {
+			logLevels[existing] = existingEnabled
+		}
+		logLevels[level] = enabled
+		settings.config.LogLevels = logLevels
+	})
 }
 
 func (l *OmniLogger) Debugf(format string, args ...interface{}) {
 	message := fmt.Sprintf(format, args...)
-	l.logWritter(DEBUG, message)
+	l.log(DEBUG, message)
 }
 
 func (l *OmniLogger) Infof(format string, args ...interface{}) {
 	message := fmt.Sprintf(format, args...)
-	l.logWritter(INFO, message)
+	l.log(INFO, message)
 }
 
 func (l *OmniLogger) Warnf(format string, args ...interface{}) {
 	message := fmt.Sprintf(format, args...)
-	l.logWritter(WARN, message)
+	l.log(WARN, message)
 }
 
 func (l *OmniLogger) Errorf(format string, args ...interface{}) {
 	message := fmt.Sprintf(format, args...)
-	l.logWritter(ERROR, message)
+	l.log(ERROR, message)
 }
 
 func (l *OmniLogger) Fatalf(format string, args ...interface{}) {
 	message := fmt.Sprintf(format, args...)
-	l.logWritter(FATAL, message)
-	os.Exit(1)
+	l.log(FATAL, message)
 }
 
 func (l *OmniLogger) Logf(level config.LogLevel, format string, args ...interface{}) {
 	message := fmt.Sprintf(format, args...)
-	l.logWritter(level, message)
-	os.Exit(1)
+	l.log(level, message)
 }
 
 func (l *OmniLogger) Log(level config.LogLevel, message string) {
-	l.logWritter(level, message)
+	l.log(level, message)
 }
 
 func (l *OmniLogger) Debug(message string) {
-	l.logWritter(DEBUG, message)
+	l.log(DEBUG, message)
 }
 
 func (l *OmniLogger) Info(message string) {
-	l.logWritter(INFO, message)
+	l.log(INFO, message)
 }
 
 func (l *OmniLogger) Warn(message string) {
-	l.logWritter(WARN, message)
+	l.log(WARN, message)
 }
 
 func (l *OmniLogger) Error(message string) {
-	l.logWritter(ERROR, message)
+	l.log(ERROR, message)
 }
 
 func (l *OmniLogger) Fatal(message string) {
-	l.logWritter(FATAL, message)
-	os.Exit(1)
+	l.log(FATAL, message)
 }
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+)
+
+// maxErrorDepth bounds how far DescribeError follows wrapped errors, guarding against cycles.
+const maxErrorDepth = 32
+
+// ErrorInfo is the structured description of an error attached to a log entry.
+type ErrorInfo struct {
+	Message    string      `json:"message"`               // The error message.
+	Type       string      `json:"type"`                  // The concrete type, for example *fs.PathError.
+	StackTrace []Frame     `json:"stack_trace,omitempty"` // The stack the error carries, if any.
+	Cause      *ErrorInfo  `json:"cause,omitempty"`       // The error returned by Unwrap() error.
+	Errors     []ErrorInfo `json:"errors,omitempty"`      // The errors returned by Unwrap() []error, such as errors.Join branches.
+}
+
+// DescribeError follows the Unwrap chain and every errors.Join branch of err.
+// Stacks are read from errors with a Callers() []uintptr method or a StackTrace() method
+// returning a slice of program counters, as github.com/pkg/errors provides.
+func DescribeError(err error) *ErrorInfo {
+	return describeError(err, 0)
+}
+
+func describeError(err error, depth int) *ErrorInfo {
+	if err == nil || depth >= maxErrorDepth {
+		return nil
+	}
+
+	info := &ErrorInfo{
+		Message:    err.Error(),
+		Type:       fmt.Sprintf("%T", err),
+		StackTrace: errorStack(err),
+	}
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() error }:
+		info.Cause = describeError(wrapped.Unwrap(), depth+1)
+	case interface{ Unwrap() []error }:
+		for _, branch := range wrapped.Unwrap() {
+			if branchInfo := describeError(branch, depth+1); branchInfo != nil {
+				info.Errors = append(info.Errors, *branchInfo)
+			}
+		}
+	}
+	return info
+}
+
+// errorStack returns the stack carried by err itself, ignoring the errors it wraps.
+func errorStack(err error) []Frame {
+	var pcs []uintptr
+	if carrier, ok := err.(interface{ Callers() []uintptr }); ok {
+		pcs = carrier.Callers()
+	} else if method := reflect.ValueOf(err).MethodByName("StackTrace"); method.IsValid() {
+		// Matches StackTrace() methods returning any slice of program counters without importing their package.
+		if method.Type().NumIn() == 0 && method.Type().NumOut() == 1 {
+			result := method.Call(nil)[0]
+			if result.Kind() == reflect.Slice && result.Type().Elem().Kind() == reflect.Uintptr {
+				pcs = make([]uintptr, result.Len())
+				for i := range pcs {
+					pcs[i] = uintptr(result.Index(i).Uint())
+				}
+			}
+		}
+	}
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	stack := make([]Frame, 0, len(pcs))
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		if !more {
+			return stack
+		}
+	}
+}
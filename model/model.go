@@ -1,17 +1,140 @@
 package model
 
+import (
+	"fmt"
+	"slices"
+)
+
 // MessageData represents the structure of a log message.
 type MessageData struct {
 	Level      string   // The log level of the message.
 	Message    string   // The actual log message.
-	StackTrace string   // The stack trace at the time of logging.
+	Caller     Frame    // The code that logged the message.
+	StackTrace []Frame  // The full call stack, starting at Caller, for levels that capture one.
+	Goroutines string   // A dump of all goroutines, for terminal levels when enabled.
+	Error      error    // The error attached with Err, if any.
 	Context    *Context // Contextual information for the log entry.
 	Timestamp  string   // The timestamp when the log entry was created.
 }
 
+// Frame describes a single location in the program.
+type Frame struct {
+	Function string `json:"function"` // Fully qualified function name.
+	File     string `json:"file"`     // Full path of the source file.
+	Line     int    `json:"line"`     // Line number in File.
+}
+
+// String formats the frame as "file:line function", or "unknown" for the zero frame.
+func (f Frame) String() string {
+	if f.File == "" {
+		return "unknown"
+	}
+	if f.Function == "" {
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+	return fmt.Sprintf("%s:%d %s", f.File, f.Line, f.Function)
+}
+
 // Context holds contextual information for a log entry.
 type Context struct {
 	TransactionID string                 // Unique identifier for the transaction.
 	UserID        string                 // Identifier for the user associated with the log.
 	MetaData      map[string]interface{} // Additional metadata related to the log entry.
+
+	keys []string // MetaData keys in the order Merge added them.
+}
+
+// Clone returns a copy of the context that owns its MetaData map. A nil context clones to an empty one.
+func (c *Context) Clone() *Context {
+	clone := &Context{MetaData: map[string]interface{}{}}
+	if c == nil {
+		return clone
+	}
+	clone.TransactionID = c.TransactionID
+	clone.UserID = c.UserID
+	for key, value := range c.MetaData {
+		clone.MetaData[key] = value
+	}
+	for _, key := range c.keys {
+		if _, ok := c.MetaData[key]; ok {
+			clone.keys = append(clone.keys, key)
+		}
+	}
+	return clone
+}
+
+// Merge returns a new context holding the fields of c overlaid with the non-empty fields of other.
+// Neither c nor other is modified.
+func (c *Context) Merge(other *Context) *Context {
+	merged := c.Clone()
+	if other == nil {
+		return merged
+	}
+	if other.TransactionID != "" {
+		merged.TransactionID = other.TransactionID
+	}
+	if other.UserID != "" {
+		merged.UserID = other.UserID
+	}
+	for _, key := range other.Keys() {
+		if _, exists := merged.MetaData[key]; !exists {
+			merged.keys = append(merged.keys, key)
+		}
+		merged.MetaData[key] = other.MetaData[key]
+	}
+	return merged
+}
+
+// Keys returns the MetaData keys in the order they were added. Keys added in a single Merge,
+// and keys set on MetaData directly, follow in sorted order. Replacing a value keeps its position.
+func (c *Context) Keys() []string {
+	if c == nil {
+		return nil
+	}
+	return c.AppendKeys(make([]string, 0, len(c.MetaData)))
+}
+
+// AppendKeys appends the MetaData keys to dst in the order of Keys and returns the extended slice.
+func (c *Context) AppendKeys(dst []string) []string {
+	if c == nil {
+		return dst
+	}
+	start := len(dst)
+	for _, key := range c.keys {
+		if _, ok := c.MetaData[key]; ok {
+			dst = append(dst, key)
+		}
+	}
+	tracked := len(dst)
+	if tracked-start == len(c.MetaData) {
+		return dst
+	}
+	for key := range c.MetaData {
+		if !slices.Contains(dst[start:tracked], key) {
+			dst = append(dst, key)
+		}
+	}
+	slices.Sort(dst[tracked:])
+	return dst
+}
+
+// SortedKeys returns the MetaData keys sorted.
+func (c *Context) SortedKeys() []string {
+	if c == nil {
+		return nil
+	}
+	return c.AppendSortedKeys(make([]string, 0, len(c.MetaData)))
+}
+
+// AppendSortedKeys appends the sorted MetaData keys to dst and returns the extended slice.
+func (c *Context) AppendSortedKeys(dst []string) []string {
+	if c == nil {
+		return dst
+	}
+	start := len(dst)
+	for key := range c.MetaData {
+		dst = append(dst, key)
+	}
+	slices.Sort(dst[start:])
+	return dst
 }
@@ -6,3 +6,38 @@ type LoggerDriver interface {
 	WriteLog(message string) error
 	FormatLog(messageData model.MessageData) (string, error)
 }
+
+// EntryWriter is implemented by drivers that consume structured entries directly.
+// The logger calls WriteEntry instead of FormatLog and WriteLog for such drivers.
+type EntryWriter interface {
+	WriteEntry(messageData model.MessageData) error
+}
+
+// LevelWriter is implemented by drivers whose writes depend on the level of the entry, such as
+// file drivers syncing errors to disk. The logger calls WriteLevel instead of WriteLog for such drivers.
+type LevelWriter interface {
+	WriteLevel(level string, message string) error
+}
+
+// Flusher is implemented by drivers that buffer output. Flush writes out everything buffered so far.
+type Flusher interface {
+	Flush() error
+}
+
+// Closer is implemented by drivers that hold resources such as open files or connections.
+type Closer interface {
+	Close() error
+}
+
+// Formatter turns an entry into the text a driver writes. Drivers that accept a Formatter
+// can be combined with any layout.
+type Formatter interface {
+	Format(messageData model.MessageData) (string, error)
+}
+
+// FormatterFunc adapts a function to the Formatter interface.
+type FormatterFunc func(messageData model.MessageData) (string, error)
+
+func (f FormatterFunc) Format(messageData model.MessageData) (string, error) {
+	return f(messageData)
+}
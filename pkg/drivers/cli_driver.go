@@ -3,9 +3,18 @@ package pkg
 import (
 	"fmt"
 	"omnilogger/model"
+	pkg "omnilogger/pkg"
 )
 
-type CLIDriver struct{}
+// CLIDriver writes entries to standard output, in the TextFormatter layout unless Formatter is set.
+type CLIDriver struct {
+	Formatter pkg.Formatter
+}
+
+// NewCLIDriver returns a CLIDriver using formatter.
+func NewCLIDriver(formatter pkg.Formatter) *CLIDriver {
+	return &CLIDriver{Formatter: formatter}
+}
 
 func (d *CLIDriver) WriteLog(message string) error {
 	fmt.Println(message)
@@ -13,25 +22,8 @@ func (d *CLIDriver) WriteLog(message string) error {
 }
 
 func (d *CLIDriver) FormatLog(messageData model.MessageData) (string, error) {
-
-	logEntry := fmt.Sprintf("[%s] timestamp: %s ", messageData.Level, messageData.Timestamp)
-
-	if messageData.Context == nil {
-		logEntry += fmt.Sprintf(" trace: %+s  msg : %s ", messageData.StackTrace, messageData.Message)
-		return logEntry, nil
+	if d.Formatter == nil {
+		return (&TextFormatter{}).Format(messageData)
 	}
-
-	if messageData.Context.TransactionID != "" {
-		logEntry += fmt.Sprintf("transaction_id: %s ", messageData.Context.TransactionID)
-	}
-	if messageData.Context.UserID != "" {
-		logEntry += fmt.Sprintf("user_id: %s ", messageData.Context.UserID)
-	}
-	for key, value := range messageData.Context.MetaData {
-		logEntry += fmt.Sprintf("%s: %v ", key, value)
-	}
-
-	logEntry += fmt.Sprintf(" trace: %+s  msg : %s ", messageData.StackTrace, messageData.Message)
-
-	return logEntry, nil
+	return d.Formatter.Format(messageData)
 }
@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+)
+
+// compressFile gzips path into path.gz and removes path. The archive keeps the modification
+// time of path, from which backup ages are measured. It is written under a temporary name
+// first, so a crash never leaves a truncated archive behind.
+func compressFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	target := path + compressedSuffix
+	temporary := target + ".tmp"
+	file, err := os.OpenFile(temporary, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	writer := gzip.NewWriter(file)
+	if _, err := io.Copy(writer, source); err != nil {
+		file.Close()
+		os.Remove(temporary)
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		file.Close()
+		os.Remove(temporary)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(temporary)
+		return err
+	}
+
+	if err := os.Chtimes(temporary, info.ModTime(), info.ModTime()); err != nil {
+		os.Remove(temporary)
+		return err
+	}
+	if err := os.Rename(temporary, target); err != nil {
+		os.Remove(temporary)
+		return err
+	}
+	source.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,199 @@
+package pkg
+
+import (
+	"fmt"
+	"omnilogger/config"
+	"omnilogger/model"
+	pkg "omnilogger/pkg"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ColorMode selects when ConsoleFormatter uses ANSI colors.
+type ColorMode int
+
+const (
+	ColorAuto   ColorMode = iota // Color when ColorEnabled reports true for the output of the driver, standard output for Format.
+	ColorAlways                  // Always color.
+	ColorNever                   // Never color.
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+	ansiBold   = "\x1b[1;35m"
+)
+
+// levelColors maps the built-in levels to their color, other levels are not colored.
+var levelColors = map[string]string{
+	"DEBUG": ansiCyan,
+	"INFO":  ansiGreen,
+	"WARN":  ansiYellow,
+	"ERROR": ansiRed,
+	"FATAL": ansiBold,
+}
+
+const (
+	defaultConsoleTimeLayout = "15:04:05.000"
+	consoleLevelWidth        = 5
+	consoleCallerWidth       = 24
+	consoleMessageWidth      = 40
+)
+
+// ConsoleFormatter renders entries for people reading a terminal: colored levels, aligned
+// columns, short caller paths and dimmed metadata keys. Errors and stack traces follow on the
+// lines below, as with TextFormatter.
+//
+//	10:04:05.123 INFO  orders/service.go:42      order saved                              user_id=user456 order_id=7
+type ConsoleFormatter struct {
+	Color      ColorMode // ColorAuto when not set.
+	TimeLayout string    // Layout of the time column, "15:04:05.000" when not set.
+
+	MetadataOrder config.MetadataOrder // Order of the metadata fields, sorted when not set.
+
+	colors sync.Map // ColorEnabled by output file, so it is checked once per file.
+}
+
+// NewConsoleFormatter returns a ConsoleFormatter using mode.
+func NewConsoleFormatter(mode ColorMode) *ConsoleFormatter {
+	return &ConsoleFormatter{Color: mode}
+}
+
+// ColorEnabled reports whether output to file should be colored. NO_COLOR disables colors and
+// FORCE_COLOR enables them, otherwise colors are used when file is a terminal and TERM is not "dumb".
+func ColorEnabled(file *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("FORCE_COLOR"); force != "" {
+		return force != "0" && force != "false"
+	}
+	if os.Getenv("TERM") == "dumb" || file == nil {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// useColor reports whether entries written to output are colored. A nil output is not a terminal.
+func (f *ConsoleFormatter) useColor(output *os.File) bool {
+	switch f.Color {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if color, ok := f.colors.Load(output); ok {
+		return color.(bool)
+	}
+	color := ColorEnabled(output)
+	f.colors.Store(output, color)
+	return color
+}
+
+// Format renders the entry for standard output.
+func (f *ConsoleFormatter) Format(messageData model.MessageData) (string, error) {
+	return f.format(messageData, f.useColor(os.Stdout))
+}
+
+// formatFor formats the entry with formatter for a driver writing to output, so ColorAuto checks
+// the file the entry actually goes to. Drivers writing elsewhere than a file pass nil.
+func formatFor(formatter pkg.Formatter, messageData model.MessageData, output *os.File) (string, error) {
+	if console, ok := formatter.(*ConsoleFormatter); ok {
+		return console.format(messageData, console.useColor(output))
+	}
+	return formatter.Format(messageData)
+}
+
+func (f *ConsoleFormatter) format(messageData model.MessageData, color bool) (string, error) {
+	paint := func(code, text string) string {
+		if !color || code == "" {
+			return text
+		}
+		return code + text + ansiReset
+	}
+
+	var b strings.Builder
+	b.WriteString(paint(ansiDim, f.formatTime(messageData.Timestamp)))
+	b.WriteByte(' ')
+	b.WriteString(paint(levelColors[messageData.Level], pad(messageData.Level, consoleLevelWidth)))
+	b.WriteByte(' ')
+	b.WriteString(paint(ansiDim, pad(shortCaller(messageData.Caller), consoleCallerWidth)))
+	b.WriteByte(' ')
+
+	fields := consoleFields(messageData.Context, f.MetadataOrder)
+	if len(fields) == 0 {
+		b.WriteString(messageData.Message)
+	} else {
+		b.WriteString(pad(messageData.Message, consoleMessageWidth))
+	}
+	for _, field := range fields {
+		b.WriteByte(' ')
+		b.WriteString(paint(ansiDim, field[0]+"="))
+		b.WriteString(field[1])
+	}
+
+	if messageData.Error != nil {
+		b.WriteString(paint(ansiRed, formatError(messageData.Error)))
+	}
+	b.WriteString(formatStack(messageData))
+	return b.String(), nil
+}
+
+func (f *ConsoleFormatter) formatTime(timestamp string) string {
+	parsed, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return timestamp
+	}
+	layout := f.TimeLayout
+	if layout == "" {
+		layout = defaultConsoleTimeLayout
+	}
+	return parsed.Format(layout)
+}
+
+// consoleFields returns the context as key/value pairs: transaction_id and user_id first,
+// then the metadata in the given order.
+func consoleFields(ctx *model.Context, order config.MetadataOrder) [][2]string {
+	if ctx == nil {
+		return nil
+	}
+	var fields [][2]string
+	if ctx.TransactionID != "" {
+		fields = append(fields, [2]string{"transaction_id", ctx.TransactionID})
+	}
+	if ctx.UserID != "" {
+		fields = append(fields, [2]string{"user_id", ctx.UserID})
+	}
+
+	for _, key := range metadataKeys(ctx, order) {
+		fields = append(fields, [2]string{key, fmt.Sprintf("%v", ctx.MetaData[key])})
+	}
+	return fields
+}
+
+// shortCaller keeps the last directory and the file name of the caller, "orders/service.go:42".
+func shortCaller(frame model.Frame) string {
+	if frame.File == "" {
+		return "unknown"
+	}
+	dir, file := filepath.Split(frame.File)
+	return fmt.Sprintf("%s:%d", filepath.Join(filepath.Base(dir), file), frame.Line)
+}
+
+// pad right-pads text with spaces to width characters.
+func pad(text string, width int) string {
+	length := utf8.RuneCountInString(text)
+	if length >= width {
+		return text
+	}
+	return text + strings.Repeat(" ", width-length)
+}
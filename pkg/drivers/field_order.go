@@ -0,0 +1,44 @@
+package pkg
+
+import (
+	"omnilogger/config"
+	"omnilogger/model"
+)
+
+// fieldSequence returns the fields a formatter writes: those listed in order first, then the
+// rest of defaults in their default order. The metadata block comes last unless it is listed.
+func fieldSequence(order config.FieldOrder, defaults []string) []string {
+	if len(order.Fields) == 0 {
+		return defaults
+	}
+
+	known := make(map[string]bool, len(defaults))
+	for _, field := range defaults {
+		known[field] = true
+	}
+	sequence := make([]string, 0, len(defaults))
+	listed := make(map[string]bool, len(order.Fields))
+	for _, field := range order.Fields {
+		if known[field] && !listed[field] {
+			sequence = append(sequence, field)
+			listed[field] = true
+		}
+	}
+	for _, field := range defaults {
+		if !listed[field] && field != config.FieldMetadata {
+			sequence = append(sequence, field)
+		}
+	}
+	if known[config.FieldMetadata] && !listed[config.FieldMetadata] {
+		sequence = append(sequence, config.FieldMetadata)
+	}
+	return sequence
+}
+
+// metadataKeys returns the metadata keys of ctx in the given order.
+func metadataKeys(ctx *model.Context, order config.MetadataOrder) []string {
+	if order == config.MetadataInsertion {
+		return ctx.Keys()
+	}
+	return ctx.SortedKeys()
+}
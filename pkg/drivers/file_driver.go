@@ -1,57 +1,352 @@
 package pkg
 
 import (
-	"encoding/json"
+	"bufio"
+	"errors"
 	"fmt"
+	"omnilogger/config"
 	"omnilogger/model"
+	pkg "omnilogger/pkg"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
 )
 
+// Defaults of FileOptions.
+const (
+	DefaultFileMode      os.FileMode = 0666 // Permissions of created log files, before the umask.
+	DefaultDirMode       os.FileMode = 0755 // Permissions of created parent directories, before the umask.
+	DefaultCheckInterval             = time.Second
+)
+
+// FileOptions controls how FileDriver writes to its file.
+type FileOptions struct {
+	BufferSize    int               // Bytes buffered in memory before a write, unbuffered when not set.
+	FlushInterval time.Duration     // Longest time entries stay buffered, config.DefaultFlushInterval when not set.
+	Durability    config.Durability // What happens to entries at SyncLevel and above, config.DurabilityFsync when not set.
+	SyncLevel     config.LogLevel   // Lowest level flushed and synced at once, ERROR when not set.
+
+	FileMode os.FileMode // Permissions of a created file, DefaultFileMode when not set.
+	DirMode  os.FileMode // Permissions of created parent directories, DefaultDirMode when not set.
+
+	// CheckInterval is how often writes check that the path still names the open file, so a file
+	// moved or deleted by logrotate is recreated. DefaultCheckInterval when not set, never when negative.
+	CheckInterval time.Duration
+
+	ReopenOnSIGHUP bool            // Reopen the file when the process receives SIGHUP.
+	OnError        func(err error) // Receives errors of reopening on a signal, written to stderr when not set.
+}
+
+// FileDriver appends entries to a file, as JSON unless Formatter is set. Entries can be buffered
+// in memory, in which case entries at the sync level are flushed, and by default synced to disk,
+// before the logging call returns.
+//
+// The driver works with external rotation such as logrotate. When the file is moved or deleted,
+// detected by comparing its device and inode with those of the path, the next write after the
+// check interval recreates it. Reopen, called directly or on SIGHUP, does the same at once.
+// Entries buffered at that point are written to the old file.
 type FileDriver struct {
-	file *os.File
+	Formatter pkg.Formatter
+
+	mu           sync.Mutex
+	path         string
+	file         *os.File
+	info         os.FileInfo   // Identity of the open file.
+	writer       *bufio.Writer // Nil when unbuffered.
+	options      FileOptions
+	syncSeverity config.Severity
+	lastCheck    time.Time
+	closed       bool // Set by Close, after which the file is never reopened.
+
+	stop       chan struct{}
+	stopOnce   sync.Once
+	flushDone  sync.WaitGroup
+	stopSignal func()
 }
 
+// NewFileDriver opens filePath for appending. Every entry is written at once and never synced.
 func NewFileDriver(filePath string) (*FileDriver, error) {
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	return NewFileDriverWithOptions(filePath, FileOptions{Durability: config.DurabilityNone})
+}
+
+// NewFileDriverWithOptions opens filePath for appending with the buffering and durability of options.
+func NewFileDriverWithOptions(filePath string, options FileOptions) (*FileDriver, error) {
+	if options.Durability == "" {
+		options.Durability = config.DurabilityFsync
+	}
+	switch options.Durability {
+	case config.DurabilityNone, config.DurabilityFlush, config.DurabilityFsync:
+	default:
+		return nil, fmt.Errorf("unknown durability: %q", options.Durability)
+	}
+	if options.SyncLevel == "" {
+		options.SyncLevel = config.ERROR
+	}
+	syncSeverity, ok := config.SeverityOf(options.SyncLevel)
+	if !ok {
+		return nil, fmt.Errorf("unknown sync level: %q", options.SyncLevel)
+	}
+
+	if options.FileMode == 0 {
+		options.FileMode = DefaultFileMode
+	}
+	if options.DirMode == 0 {
+		options.DirMode = DefaultDirMode
+	}
+	if options.CheckInterval == 0 {
+		options.CheckInterval = DefaultCheckInterval
+	}
+	if options.BufferSize > 0 && options.FlushInterval <= 0 {
+		options.FlushInterval = config.DefaultFlushInterval
+	}
+	if options.OnError == nil {
+		options.OnError = func(err error) {
+			fmt.Fprintf(os.Stderr, "omnilogger: %v\n", err)
+		}
+	}
+
+	d := &FileDriver{path: filePath, options: options, syncSeverity: syncSeverity}
+	file, info, err := d.openFile()
 	if err != nil {
 		return nil, err
 	}
-	return &FileDriver{file: file}, nil
+	d.file, d.info, d.lastCheck = file, info, time.Now()
+
+	if options.BufferSize > 0 {
+		d.writer = bufio.NewWriterSize(file, options.BufferSize)
+		d.stop = make(chan struct{})
+		d.flushDone.Add(1)
+		go d.flushLoop(options.FlushInterval)
+	}
+	if options.ReopenOnSIGHUP {
+		d.stopSignal = d.reopenOnSignal(syscall.SIGHUP)
+	}
+	return d, nil
+}
+
+// NewFileDriverFromConfig opens the file described by cfg, usually an entry of the Files section of a config file.
+func NewFileDriverFromConfig(cfg config.FileConfig) (*FileDriver, error) {
+	return NewFileDriverWithOptions(cfg.Path, FileOptions{
+		BufferSize:     cfg.BufferSize,
+		FlushInterval:  time.Duration(cfg.FlushInterval),
+		Durability:     cfg.Durability,
+		SyncLevel:      cfg.SyncLevel,
+		FileMode:       os.FileMode(cfg.FileMode),
+		DirMode:        os.FileMode(cfg.DirMode),
+		CheckInterval:  time.Duration(cfg.CheckInterval),
+		ReopenOnSIGHUP: cfg.ReopenOnSIGHUP,
+	})
 }
 
 func (d *FileDriver) WriteLog(message string) error {
-	_, err := fmt.Fprintln(d.file, message)
-	return err
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.write(message, false)
 }
 
-func (d *FileDriver) FormatLog(messageData model.MessageData) (string, error) {
-	logEntry := map[string]interface{}{
-		"level":     messageData.Level,
-		"timestamp": messageData.Timestamp,
+// WriteLevel writes message and, for levels at the sync level and above, flushes and syncs
+// the file as the durability asks.
+func (d *FileDriver) WriteLevel(level string, message string) error {
+	severity, ok := config.SeverityOf(config.LogLevel(level))
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.write(message, ok && severity >= d.syncSeverity)
+}
+
+// write appends message to the open file. When the path cannot be reopened the entry still goes
+// to the current file, and the reopen error is returned along with the result of the write.
+func (d *FileDriver) write(message string, sync bool) error {
+	if d.closed {
+		return os.ErrClosed
+	}
+	var reopenErr error
+	if d.options.CheckInterval > 0 && time.Since(d.lastCheck) >= d.options.CheckInterval {
+		d.lastCheck = time.Now()
+		reopenErr = d.reopenIfMoved()
 	}
+	return errors.Join(reopenErr, d.writeMessage(message, sync))
+}
 
-	if messageData.Context != nil {
-		if messageData.Context.TransactionID != "" {
-			logEntry["transaction_id"] = messageData.Context.TransactionID
+func (d *FileDriver) writeMessage(message string, sync bool) error {
+	if d.writer == nil {
+		if _, err := fmt.Fprintln(d.file, message); err != nil {
+			return err
 		}
-		if messageData.Context.UserID != "" {
-			logEntry["user_id"] = messageData.Context.UserID
+	} else {
+		// bufio.Writer keeps the first error, so a failed background flush surfaces here.
+		d.writer.WriteString(message)
+		if err := d.writer.WriteByte('\n'); err != nil {
+			return err
 		}
-		for key, value := range messageData.Context.MetaData {
-			logEntry[key] = value
+	}
+	if sync && d.options.Durability != config.DurabilityNone {
+		return d.flush()
+	}
+	return nil
+}
+
+// flush writes out the buffer and syncs the file under DurabilityFsync. d.mu must be held.
+func (d *FileDriver) flush() error {
+	if d.writer != nil {
+		if err := d.writer.Flush(); err != nil {
+			return err
 		}
 	}
+	if d.options.Durability == config.DurabilityFsync {
+		return d.file.Sync()
+	}
+	return nil
+}
 
-	logEntry["stack_trace"] = messageData.StackTrace
-	logEntry["message"] = messageData.Message
+// Reopen closes the file and opens its path again, creating it when it no longer exists.
+// Entries buffered so far are written to the old file first.
+func (d *FileDriver) Reopen() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return os.ErrClosed
+	}
+	return d.reopen()
+}
 
-	jsonData, err := json.Marshal(logEntry)
+// reopen switches to a newly opened file, keeping the old one when the path cannot be opened.
+// d.mu must be held.
+func (d *FileDriver) reopen() error {
+	file, info, err := d.openFile()
 	if err != nil {
-		return "", err
+		return fmt.Errorf("reopen %s: %w", d.path, err)
+	}
+
+	var flushErr error
+	if d.writer != nil {
+		flushErr = d.writer.Flush()
+		d.writer.Reset(file)
+	}
+	closeErr := d.file.Close()
+	d.file, d.info = file, info
+	if flushErr != nil {
+		return flushErr
+	}
+	return closeErr
+}
+
+// reopenIfMoved reopens the path when it is missing or names another file than the open one. d.mu must be held.
+func (d *FileDriver) reopenIfMoved() error {
+	info, err := os.Stat(d.path)
+	if err == nil && os.SameFile(info, d.info) {
+		return nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reopen %s: %w", d.path, err)
+	}
+	return d.reopen()
+}
+
+// openFile opens the path for appending, creating it and its parent directories when missing.
+func (d *FileDriver) openFile() (*os.File, os.FileInfo, error) {
+	if err := os.MkdirAll(filepath.Dir(d.path), d.options.DirMode); err != nil {
+		return nil, nil, err
+	}
+	file, err := os.OpenFile(d.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, d.options.FileMode)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+// reopenOnSignal reopens the file every time the process receives one of signals, until the returned function is called.
+func (d *FileDriver) reopenOnSignal(signals ...os.Signal) (stop func()) {
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	done := make(chan struct{})
+	var stopped sync.WaitGroup
+	stopped.Add(1)
+	go func() {
+		defer stopped.Done()
+		for {
+			select {
+			case <-received:
+				if err := d.Reopen(); err != nil {
+					d.options.OnError(err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(received)
+		close(done)
+		stopped.Wait()
+	}
+}
+
+func (d *FileDriver) flushLoop(interval time.Duration) {
+	defer d.flushDone.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.mu.Lock()
+			d.writer.Flush()
+			d.mu.Unlock()
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+func (d *FileDriver) FormatLog(messageData model.MessageData) (string, error) {
+	if d.Formatter == nil {
+		return (&JSONFormatter{}).Format(messageData)
 	}
-	return string(jsonData), nil
+	return formatFor(d.Formatter, messageData, nil)
 }
 
-func (d *FileDriver) Close() {
-	d.file.Close()
+// Flush writes out the buffered entries, syncing the file under DurabilityFsync.
+func (d *FileDriver) Flush() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return os.ErrClosed
+	}
+	return d.flush()
+}
+
+// Close flushes the buffered entries and closes the file. Later writes fail with os.ErrClosed.
+func (d *FileDriver) Close() error {
+	d.stopOnce.Do(func() {
+		if d.stop != nil {
+			close(d.stop)
+		}
+		if d.stopSignal != nil {
+			d.stopSignal()
+		}
+	})
+	d.flushDone.Wait()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+	var flushErr error
+	if d.writer != nil {
+		flushErr = d.writer.Flush()
+	}
+	if err := d.file.Close(); err != nil {
+		return err
+	}
+	return flushErr
 }
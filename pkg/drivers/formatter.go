@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"fmt"
+	"omnilogger/config"
+	pkg "omnilogger/pkg"
+	"strings"
+)
+
+// NewFormatter builds the formatter described by cfg, usually the Format section of a config file.
+// It returns nil when cfg is empty, so drivers keep their default format.
+//
+//	cfg, _ := config.LoadConfig("config.json")
+//	formatter, err := drivers.NewFormatter(cfg.Format)
+func NewFormatter(cfg config.FormatConfig) (pkg.Formatter, error) {
+	formatType := strings.ToLower(cfg.Type)
+	if formatType == "" && cfg.Pattern != "" {
+		formatType = "pattern"
+	}
+
+	if err := cfg.Order.Validate(); err != nil {
+		return nil, err
+	}
+	switch cfg.KeyCollision {
+	case "", config.KeyCollisionPrefix, config.KeyCollisionNest, config.KeyCollisionError:
+	default:
+		return nil, fmt.Errorf("unknown key collision strategy: %q", cfg.KeyCollision)
+	}
+
+	switch formatType {
+	case "":
+		return nil, nil
+	case "text":
+		return &TextFormatter{Order: cfg.Order}, nil
+	case "json":
+		return &JSONFormatter{Order: cfg.Order, KeyCollision: cfg.KeyCollision}, nil
+	case "logfmt":
+		return &LogfmtFormatter{Order: cfg.Order}, nil
+	case "console":
+		formatter := NewConsoleFormatter(ColorAuto)
+		formatter.MetadataOrder = cfg.Order.Metadata
+		return formatter, nil
+	case "pattern":
+		if cfg.Pattern == "" {
+			return nil, fmt.Errorf("pattern formatter needs a pattern")
+		}
+		formatter, err := NewPatternFormatter(cfg.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		formatter.MetadataOrder = cfg.Order.Metadata
+		return formatter, nil
+	default:
+		return nil, fmt.Errorf("unknown formatter type: %q", cfg.Type)
+	}
+}
@@ -1,13 +1,16 @@
 package pkg
 
 import (
-	"encoding/json"
 	"fmt"
 	"omnilogger/model"
+	pkg "omnilogger/pkg"
 )
 
-// JsonCliDriver implements the LoggerDriver interface for JSON output to CLI
-type JsonCliDriver struct{}
+// JsonCliDriver implements the LoggerDriver interface for JSON output to CLI.
+// It behaves like a CLIDriver whose Formatter defaults to JSONFormatter.
+type JsonCliDriver struct {
+	Formatter pkg.Formatter
+}
 
 func (d *JsonCliDriver) WriteLog(message string) error {
 	fmt.Println(message)
@@ -15,27 +18,8 @@ func (d *JsonCliDriver) WriteLog(message string) error {
 }
 
 func (d *JsonCliDriver) FormatLog(messageData model.MessageData) (string, error) {
-	logEntry := map[string]interface{}{
-		"level":     messageData.Level,
-		"timestamp": messageData.Timestamp,
-	}
-	if messageData.Context != nil {
-		if messageData.Context.TransactionID != "" {
-			logEntry["transaction_id"] = messageData.Context.TransactionID
-		}
-		if messageData.Context.UserID != "" {
-			logEntry["user_id"] = messageData.Context.UserID
-		}
-		for key, value := range messageData.Context.MetaData {
-			logEntry[key] = value
-		}
-	}
-	logEntry["stack_trace"] = messageData.StackTrace
-	logEntry["message"] = messageData.Message
-
-	jsonData, err := json.Marshal(logEntry)
-	if err != nil {
-		return "", err
+	if d.Formatter == nil {
+		return (&JSONFormatter{}).Format(messageData)
 	}
-	return string(jsonData), nil
+	return d.Formatter.Format(messageData)
 }
@@ -0,0 +1,299 @@
+package pkg
+
+import (
+	"encoding"
+	"encoding/json"
+	"math"
+	"omnilogger/model"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxPooledBuffer bounds the buffers kept in jsonEncoderPool, so one huge entry does not pin its memory.
+const maxPooledBuffer = 64 << 10
+
+var jsonEncoderPool = sync.Pool{
+	New: func() interface{} {
+		return &jsonEncoder{buf: make([]byte, 0, 1024)}
+	},
+}
+
+// jsonEncoder appends JSON to a reusable buffer. Its output matches encoding/json byte for byte,
+// HTML escaping included, for every value it encodes itself; other values go through json.Marshal.
+type jsonEncoder struct {
+	buf  []byte
+	keys []string // Scratch space for sorting map keys.
+}
+
+func getJSONEncoder() *jsonEncoder {
+	return jsonEncoderPool.Get().(*jsonEncoder)
+}
+
+func putJSONEncoder(e *jsonEncoder) {
+	if cap(e.buf) > maxPooledBuffer {
+		return
+	}
+	e.buf = e.buf[:0]
+	e.keys = e.keys[:0]
+	jsonEncoderPool.Put(e)
+}
+
+// key writes the separator before an object member and its key.
+func (e *jsonEncoder) key(key string) {
+	e.separator()
+	e.buf = appendJSONString(e.buf, key)
+	e.buf = append(e.buf, ':')
+}
+
+// separator writes a comma unless the value is the first of its object or array.
+func (e *jsonEncoder) separator() {
+	if n := len(e.buf); n > 0 && e.buf[n-1] != '{' && e.buf[n-1] != '[' {
+		e.buf = append(e.buf, ',')
+	}
+}
+
+func (e *jsonEncoder) stringField(key, value string) {
+	e.key(key)
+	e.buf = appendJSONString(e.buf, value)
+}
+
+// value writes value with the fast paths for the types common in metadata.
+func (e *jsonEncoder) value(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		e.buf = append(e.buf, "null"...)
+	case string:
+		e.buf = appendJSONString(e.buf, v)
+	case bool:
+		e.buf = strconv.AppendBool(e.buf, v)
+	case int:
+		e.buf = strconv.AppendInt(e.buf, int64(v), 10)
+	case int8:
+		e.buf = strconv.AppendInt(e.buf, int64(v), 10)
+	case int16:
+		e.buf = strconv.AppendInt(e.buf, int64(v), 10)
+	case int32:
+		e.buf = strconv.AppendInt(e.buf, int64(v), 10)
+	case int64:
+		e.buf = strconv.AppendInt(e.buf, v, 10)
+	case uint:
+		e.buf = strconv.AppendUint(e.buf, uint64(v), 10)
+	case uint8:
+		e.buf = strconv.AppendUint(e.buf, uint64(v), 10)
+	case uint16:
+		e.buf = strconv.AppendUint(e.buf, uint64(v), 10)
+	case uint32:
+		e.buf = strconv.AppendUint(e.buf, uint64(v), 10)
+	case uint64:
+		e.buf = strconv.AppendUint(e.buf, v, 10)
+	case float64:
+		return e.float(v, 64)
+	case float32:
+		return e.float(float64(v), 32)
+	case time.Duration:
+		e.buf = strconv.AppendInt(e.buf, int64(v), 10)
+	case time.Time:
+		return e.time(v)
+	case map[string]interface{}:
+		return e.object(v)
+	case json.Marshaler, encoding.TextMarshaler:
+		return e.marshal(v)
+	case error:
+		e.buf = appendJSONString(e.buf, v.Error())
+	default:
+		return e.marshal(v)
+	}
+	return nil
+}
+
+// marshal writes value through json.Marshal, the path for every type without a fast path.
+func (e *jsonEncoder) marshal(value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	e.buf = append(e.buf, encoded...)
+	return nil
+}
+
+// float writes f as encoding/json does: the shortest representation, in exponent form for
+// very small and very large magnitudes.
+func (e *jsonEncoder) float(f float64, bits int) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		if bits == 32 {
+			return e.marshal(float32(f))
+		}
+		return e.marshal(f)
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	e.buf = strconv.AppendFloat(e.buf, f, format, -1, bits)
+	if format == 'e' {
+		// Shorten e-09 to e-9, as encoding/json does.
+		if n := len(e.buf); n >= 4 && e.buf[n-4] == 'e' && e.buf[n-3] == '-' && e.buf[n-2] == '0' {
+			e.buf[n-2] = e.buf[n-1]
+			e.buf = e.buf[:n-1]
+		}
+	}
+	return nil
+}
+
+// time writes t in RFC 3339 with nanoseconds, leaving the times time.MarshalJSON rejects to json.Marshal.
+func (e *jsonEncoder) time(t time.Time) error {
+	_, offset := t.Zone()
+	if year := t.Year(); year < 0 || year > 9999 || offset%60 != 0 || offset <= -24*60*60 || offset >= 24*60*60 {
+		return e.marshal(t)
+	}
+	e.buf = append(e.buf, '"')
+	e.buf = t.AppendFormat(e.buf, time.RFC3339Nano)
+	e.buf = append(e.buf, '"')
+	return nil
+}
+
+// object writes a nested map with its keys sorted, as encoding/json does.
+func (e *jsonEncoder) object(fields map[string]interface{}) error {
+	start := len(e.keys)
+	for key := range fields {
+		e.keys = append(e.keys, key)
+	}
+	end := len(e.keys)
+	slices.Sort(e.keys[start:end])
+
+	e.buf = append(e.buf, '{')
+	for i := start; i < end; i++ {
+		// Nested objects append to e.keys, so it is indexed again on every iteration.
+		key := e.keys[i]
+		e.key(key)
+		if err := e.value(fields[key]); err != nil {
+			return err
+		}
+	}
+	e.buf = append(e.buf, '}')
+	e.keys = e.keys[:start]
+	return nil
+}
+
+// caller writes frame as the string model.Frame.String returns.
+func (e *jsonEncoder) caller(frame model.Frame) {
+	if frame.File == "" {
+		e.buf = append(e.buf, `"unknown"`...)
+		return
+	}
+	e.buf = append(e.buf, '"')
+	e.buf = appendJSONStringContent(e.buf, frame.File)
+	e.buf = append(e.buf, ':')
+	e.buf = strconv.AppendInt(e.buf, int64(frame.Line), 10)
+	if frame.Function != "" {
+		e.buf = append(e.buf, ' ')
+		e.buf = appendJSONStringContent(e.buf, frame.Function)
+	}
+	e.buf = append(e.buf, '"')
+}
+
+func (e *jsonEncoder) frames(frames []model.Frame) {
+	e.buf = append(e.buf, '[')
+	for _, frame := range frames {
+		e.separator()
+		e.buf = append(e.buf, '{')
+		e.stringField("function", frame.Function)
+		e.stringField("file", frame.File)
+		e.key("line")
+		e.buf = strconv.AppendInt(e.buf, int64(frame.Line), 10)
+		e.buf = append(e.buf, '}')
+	}
+	e.buf = append(e.buf, ']')
+}
+
+// errorInfo writes info with the field names and omissions of its json tags.
+func (e *jsonEncoder) errorInfo(info *model.ErrorInfo) {
+	e.buf = append(e.buf, '{')
+	e.stringField("message", info.Message)
+	e.stringField("type", info.Type)
+	if len(info.StackTrace) > 0 {
+		e.key("stack_trace")
+		e.frames(info.StackTrace)
+	}
+	if info.Cause != nil {
+		e.key("cause")
+		e.errorInfo(info.Cause)
+	}
+	if len(info.Errors) > 0 {
+		e.key("errors")
+		e.buf = append(e.buf, '[')
+		for i := range info.Errors {
+			e.separator()
+			e.errorInfo(&info.Errors[i])
+		}
+		e.buf = append(e.buf, ']')
+	}
+	e.buf = append(e.buf, '}')
+}
+
+// appendJSONString appends s as a quoted JSON string.
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	dst = appendJSONStringContent(dst, s)
+	return append(dst, '"')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONStringContent appends s escaped as encoding/json escapes it: HTML characters and
+// U+2028 and U+2029 become \u escapes and invalid UTF-8 becomes U+FFFD.
+func appendJSONStringContent(dst []byte, s string) []byte {
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	return append(dst, s[start:]...)
+}
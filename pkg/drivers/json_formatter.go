@@ -0,0 +1,174 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"omnilogger/config"
+	"omnilogger/model"
+)
+
+// ErrReservedKey is returned by JSONFormatter under config.KeyCollisionError when a metadata key
+// collides with one of the fields it writes itself.
+var ErrReservedKey = errors.New("metadata key collides with a reserved field")
+
+// ReservedKeyPrefix is put before colliding metadata keys under config.KeyCollisionPrefix,
+// repeated when the metadata already holds the prefixed key.
+const ReservedKeyPrefix = "fields."
+
+// jsonCallerKey is the key of the caller, named before full stack traces existed and kept for existing parsers.
+const jsonCallerKey = "stack_trace"
+
+// jsonReservedKeys are the keys JSONFormatter writes itself.
+var jsonReservedKeys = map[string]bool{
+	config.FieldTimestamp:     true,
+	config.FieldLevel:         true,
+	config.FieldMessage:       true,
+	jsonCallerKey:             true,
+	config.FieldTransactionID: true,
+	config.FieldUserID:        true,
+	"error":                   true,
+	"stack":                   true,
+	"goroutines":              true,
+}
+
+// JSONFormatter renders entries as single-line JSON objects, the layout of FileDriver and JsonCliDriver.
+// Keys are written in a fixed order: the core fields, the metadata, then error, stack and goroutines.
+// The caller is written as a string under stack_trace, the key FileDriver has always used for it.
+// Metadata keys that collide with those fields are handled as KeyCollision says.
+type JSONFormatter struct {
+	Order        config.FieldOrder   // Field order, timestamp, level, message, caller, IDs and metadata when not set.
+	KeyCollision config.KeyCollision // Handling of reserved metadata keys, config.KeyCollisionPrefix when not set.
+}
+
+var jsonFieldOrder = []string{
+	config.FieldTimestamp,
+	config.FieldLevel,
+	config.FieldMessage,
+	config.FieldCaller,
+	config.FieldTransactionID,
+	config.FieldUserID,
+	config.FieldMetadata,
+}
+
+func (f *JSONFormatter) Format(messageData model.MessageData) (string, error) {
+	e := getJSONEncoder()
+	defer putJSONEncoder(e)
+
+	if err := f.encode(e, messageData); err != nil {
+		return "", err
+	}
+	return string(e.buf), nil
+}
+
+// AppendFormat appends the JSON object of Format to dst and returns the extended buffer,
+// so callers that reuse buffers can format without allocating.
+func (f *JSONFormatter) AppendFormat(dst []byte, messageData model.MessageData) ([]byte, error) {
+	e := getJSONEncoder()
+	defer putJSONEncoder(e)
+
+	if err := f.encode(e, messageData); err != nil {
+		return dst, err
+	}
+	return append(dst, e.buf...), nil
+}
+
+func (f *JSONFormatter) encode(e *jsonEncoder, messageData model.MessageData) error {
+	ctx := messageData.Context
+
+	e.buf = append(e.buf, '{')
+	for _, field := range fieldSequence(f.Order, jsonFieldOrder) {
+		switch field {
+		case config.FieldTimestamp:
+			e.stringField(field, messageData.Timestamp)
+		case config.FieldLevel:
+			e.stringField(field, messageData.Level)
+		case config.FieldMessage:
+			e.stringField(field, messageData.Message)
+		case config.FieldCaller:
+			e.key(jsonCallerKey)
+			e.caller(messageData.Caller)
+		case config.FieldTransactionID:
+			if ctx != nil && ctx.TransactionID != "" {
+				e.stringField(field, ctx.TransactionID)
+			}
+		case config.FieldUserID:
+			if ctx != nil && ctx.UserID != "" {
+				e.stringField(field, ctx.UserID)
+			}
+		case config.FieldMetadata:
+			if err := f.encodeMetadata(e, ctx); err != nil {
+				return err
+			}
+		}
+	}
+	if messageData.Error != nil {
+		e.key("error")
+		e.errorInfo(model.DescribeError(messageData.Error))
+	}
+	if len(messageData.StackTrace) > 0 {
+		e.key("stack")
+		e.frames(messageData.StackTrace)
+	}
+	if messageData.Goroutines != "" {
+		e.stringField("goroutines", messageData.Goroutines)
+	}
+	e.buf = append(e.buf, '}')
+	return nil
+}
+
+func (f *JSONFormatter) encodeMetadata(e *jsonEncoder, ctx *model.Context) error {
+	if ctx == nil || len(ctx.MetaData) == 0 {
+		return nil
+	}
+	// The keys live in the scratch space until the metadata is written.
+	start := len(e.keys)
+	if f.Order.Metadata == config.MetadataInsertion {
+		e.keys = ctx.AppendKeys(e.keys)
+	} else {
+		e.keys = ctx.AppendSortedKeys(e.keys)
+	}
+	end := len(e.keys)
+	defer func() { e.keys = e.keys[:start] }()
+
+	nested := f.KeyCollision == config.KeyCollisionNest
+	if nested {
+		e.key("fields")
+		e.buf = append(e.buf, '{')
+	}
+	for i := start; i < end; i++ {
+		key := e.keys[i]
+		switch {
+		case nested || !jsonReservedKeys[key]:
+			e.key(key)
+		case f.KeyCollision == config.KeyCollisionError:
+			return fmt.Errorf("%w: %q", ErrReservedKey, key)
+		default:
+			e.separator()
+			e.buf = append(e.buf, '"')
+			for i := prefixCount(ctx, key); i > 0; i-- {
+				e.buf = appendJSONStringContent(e.buf, ReservedKeyPrefix)
+			}
+			e.buf = appendJSONStringContent(e.buf, key)
+			e.buf = append(e.buf, '"', ':')
+		}
+		if err := e.value(ctx.MetaData[key]); err != nil {
+			return err
+		}
+	}
+	if nested {
+		e.buf = append(e.buf, '}')
+	}
+	return nil
+}
+
+// prefixCount returns how many times ReservedKeyPrefix goes before the reserved key, once unless
+// the metadata already holds the prefixed key literally, in which case the prefix is repeated.
+func prefixCount(ctx *model.Context, key string) int {
+	count := 1
+	for prefixed := ReservedKeyPrefix + key; ; prefixed = ReservedKeyPrefix + prefixed {
+		if _, exists := ctx.MetaData[prefixed]; !exists {
+			return count
+		}
+		count++
+	}
+}
@@ -0,0 +1,173 @@
+package pkg
+
+import (
+	"fmt"
+	"omnilogger/config"
+	"omnilogger/model"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// LogfmtFormatter renders entries as logfmt lines:
+//
+//	level=info ts=2024-05-01T10:00:00Z msg="order saved" caller="main.go:42 main.save" user_id=user456 order_id=7
+//
+// The core fields come first, then transaction_id and user_id, then MetaData sorted by key unless
+// Order says otherwise. Nested maps are flattened into dotted keys such as request.method.
+//
+// The error is flattened the same way: error and error_type describe it, error.cause its
+// wrapped error and error.0, error.1 the branches of errors.Join, each with its own _type and,
+// when the error carries one, _stack key.
+type LogfmtFormatter struct {
+	Order config.FieldOrder // Field order, level, ts, msg, caller, IDs and metadata when not set.
+}
+
+var logfmtFieldOrder = []string{
+	config.FieldLevel,
+	config.FieldTimestamp,
+	config.FieldMessage,
+	config.FieldCaller,
+	config.FieldTransactionID,
+	config.FieldUserID,
+	config.FieldMetadata,
+}
+
+func (f *LogfmtFormatter) Format(messageData model.MessageData) (string, error) {
+	var b strings.Builder
+	ctx := messageData.Context
+
+	for _, field := range fieldSequence(f.Order, logfmtFieldOrder) {
+		switch field {
+		case config.FieldLevel:
+			writeLogfmtPair(&b, "level", strings.ToLower(messageData.Level))
+		case config.FieldTimestamp:
+			writeLogfmtPair(&b, "ts", messageData.Timestamp)
+		case config.FieldMessage:
+			writeLogfmtPair(&b, "msg", messageData.Message)
+		case config.FieldCaller:
+			writeLogfmtPair(&b, "caller", messageData.Caller.String())
+		case config.FieldTransactionID:
+			if ctx != nil && ctx.TransactionID != "" {
+				writeLogfmtPair(&b, "transaction_id", ctx.TransactionID)
+			}
+		case config.FieldUserID:
+			if ctx != nil && ctx.UserID != "" {
+				writeLogfmtPair(&b, "user_id", ctx.UserID)
+			}
+		case config.FieldMetadata:
+			for _, key := range metadataKeys(ctx, f.Order.Metadata) {
+				writeLogfmtField(&b, key, ctx.MetaData[key])
+			}
+		}
+	}
+
+	if messageData.Error != nil {
+		writeLogfmtError(&b, "error", model.DescribeError(messageData.Error))
+	}
+	if len(messageData.StackTrace) > 0 {
+		writeLogfmtPair(&b, "stack_trace", logfmtFrames(messageData.StackTrace))
+	}
+	if messageData.Goroutines != "" {
+		writeLogfmtPair(&b, "goroutines", messageData.Goroutines)
+	}
+
+	return b.String(), nil
+}
+
+// writeLogfmtError writes info under key, followed by its cause and errors.Join branches under dotted keys.
+func writeLogfmtError(b *strings.Builder, key string, info *model.ErrorInfo) {
+	writeLogfmtPair(b, key, info.Message)
+	writeLogfmtPair(b, key+"_type", info.Type)
+	if len(info.StackTrace) > 0 {
+		writeLogfmtPair(b, key+"_stack", logfmtFrames(info.StackTrace))
+	}
+	if info.Cause != nil {
+		writeLogfmtError(b, key+".cause", info.Cause)
+	}
+	for i := range info.Errors {
+		writeLogfmtError(b, key+"."+strconv.Itoa(i), &info.Errors[i])
+	}
+}
+
+// logfmtFrames joins frames into one value, a frame per line.
+func logfmtFrames(frames []model.Frame) string {
+	lines := make([]string, len(frames))
+	for i, frame := range frames {
+		lines[i] = frame.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeLogfmtField writes a single field, flattening a nested map into dotted keys sorted by key.
+func writeLogfmtField(b *strings.Builder, key string, value interface{}) {
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		writeLogfmtPair(b, key, logfmtValue(value))
+		return
+	}
+
+	keys := make([]string, 0, len(nested))
+	for nestedKey := range nested {
+		keys = append(keys, nestedKey)
+	}
+	sort.Strings(keys)
+	for _, nestedKey := range keys {
+		writeLogfmtField(b, key+"."+nestedKey, nested[nestedKey])
+	}
+}
+
+func writeLogfmtPair(b *strings.Builder, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(logfmtKey(key))
+	b.WriteByte('=')
+	b.WriteString(quoteLogfmt(value))
+}
+
+// logfmtValue converts a metadata value to its logfmt text.
+func logfmtValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// logfmtKey replaces the characters a logfmt key cannot hold with underscores.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// quoteLogfmt quotes values that are empty or contain spaces, '=', quotes or control characters.
+func quoteLogfmt(value string) string {
+	if value == "" {
+		return `""`
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !strconv.IsPrint(r) {
+			return strconv.Quote(value)
+		}
+	}
+	return value
+}
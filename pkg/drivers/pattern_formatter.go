@@ -0,0 +1,222 @@
+package pkg
+
+import (
+	"fmt"
+	"omnilogger/config"
+	"omnilogger/model"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// PatternFormatter renders entries from a layout pattern such as
+//
+//	%time{2006-01-02 15:04:05} %-5level %caller{short} %txid %msg %meta
+//
+// Each %field may carry a width and a maximum length between the % and the name, as in fmt:
+// %10level pads on the left, %-10level pads on the right and %.20msg truncates to 20 characters.
+// An argument in braces changes how a field is rendered. %% writes a literal percent sign.
+//
+// Fields:
+//
+//	%time{layout}       the timestamp, RFC 3339 unless a Go time layout is given
+//	%level{lower}       the level, lower-cased with {lower}
+//	%msg                the message
+//	%caller{short}      "file:line function", or "dir/file:line" with {short}
+//	%file %line %func   the parts of the caller
+//	%txid %user         the transaction and user ID
+//	%meta{key}          all metadata as key=value pairs, or the value of a single key
+//	%error              the message of the attached error
+type PatternFormatter struct {
+	MetadataOrder config.MetadataOrder // Order of the pairs written by %meta, sorted when not set.
+
+	segments []patternSegment
+}
+
+type patternSegment struct {
+	literal   string
+	field     patternField
+	arg       string
+	leftAlign bool
+	width     int
+	maxLength int
+}
+
+type patternField func(f *PatternFormatter, messageData model.MessageData, arg string) string
+
+var patternFields = map[string]patternField{
+	"time": func(_ *PatternFormatter, messageData model.MessageData, arg string) string {
+		if arg == "" {
+			return messageData.Timestamp
+		}
+		parsed, err := time.Parse(time.RFC3339, messageData.Timestamp)
+		if err != nil {
+			return messageData.Timestamp
+		}
+		return parsed.Format(arg)
+	},
+	"level": func(_ *PatternFormatter, messageData model.MessageData, arg string) string {
+		if arg == "lower" {
+			return strings.ToLower(messageData.Level)
+		}
+		return messageData.Level
+	},
+	"msg": func(_ *PatternFormatter, messageData model.MessageData, _ string) string {
+		return messageData.Message
+	},
+	"caller": func(_ *PatternFormatter, messageData model.MessageData, arg string) string {
+		if arg == "short" {
+			return shortCaller(messageData.Caller)
+		}
+		return messageData.Caller.String()
+	},
+	"file": func(_ *PatternFormatter, messageData model.MessageData, _ string) string {
+		return messageData.Caller.File
+	},
+	"line": func(_ *PatternFormatter, messageData model.MessageData, _ string) string {
+		return strconv.Itoa(messageData.Caller.Line)
+	},
+	"func": func(_ *PatternFormatter, messageData model.MessageData, _ string) string {
+		return messageData.Caller.Function
+	},
+	"txid": func(_ *PatternFormatter, messageData model.MessageData, _ string) string {
+		if messageData.Context == nil {
+			return ""
+		}
+		return messageData.Context.TransactionID
+	},
+	"user": func(_ *PatternFormatter, messageData model.MessageData, _ string) string {
+		if messageData.Context == nil {
+			return ""
+		}
+		return messageData.Context.UserID
+	},
+	"meta": func(f *PatternFormatter, messageData model.MessageData, arg string) string {
+		if messageData.Context == nil {
+			return ""
+		}
+		if arg != "" {
+			value, ok := messageData.Context.MetaData[arg]
+			if !ok {
+				return ""
+			}
+			return fmt.Sprintf("%v", value)
+		}
+		keys := metadataKeys(messageData.Context, f.MetadataOrder)
+		pairs := make([]string, len(keys))
+		for i, key := range keys {
+			pairs[i] = fmt.Sprintf("%s=%v", key, messageData.Context.MetaData[key])
+		}
+		return strings.Join(pairs, " ")
+	},
+	"error": func(_ *PatternFormatter, messageData model.MessageData, _ string) string {
+		if messageData.Error == nil {
+			return ""
+		}
+		return messageData.Error.Error()
+	},
+}
+
+// NewPatternFormatter parses pattern and returns a formatter for it.
+func NewPatternFormatter(pattern string) (*PatternFormatter, error) {
+	var segments []patternSegment
+	var literal strings.Builder
+	flushLiteral := func() {
+		if literal.Len() > 0 {
+			segments = append(segments, patternSegment{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			literal.WriteByte(pattern[i])
+			continue
+		}
+		if i+1 < len(pattern) && pattern[i+1] == '%' {
+			literal.WriteByte('%')
+			i++
+			continue
+		}
+
+		segment, next, err := parsePatternField(pattern, i+1)
+		if err != nil {
+			return nil, err
+		}
+		flushLiteral()
+		segments = append(segments, segment)
+		i = next - 1
+	}
+	flushLiteral()
+
+	return &PatternFormatter{segments: segments}, nil
+}
+
+// parsePatternField parses the field starting after the % at start and returns the index following it.
+func parsePatternField(pattern string, start int) (patternSegment, int, error) {
+	var segment patternSegment
+	i := start
+	if i < len(pattern) && pattern[i] == '-' {
+		segment.leftAlign = true
+		i++
+	}
+	segment.width, i = parsePatternNumber(pattern, i)
+	if i < len(pattern) && pattern[i] == '.' {
+		segment.maxLength, i = parsePatternNumber(pattern, i+1)
+	}
+
+	nameStart := i
+	for i < len(pattern) && pattern[i] >= 'a' && pattern[i] <= 'z' {
+		i++
+	}
+	name := pattern[nameStart:i]
+	field, ok := patternFields[name]
+	if !ok {
+		return segment, i, fmt.Errorf("unknown pattern field %q at offset %d", "%"+name, start-1)
+	}
+	segment.field = field
+
+	if i < len(pattern) && pattern[i] == '{' {
+		end := strings.IndexByte(pattern[i:], '}')
+		if end < 0 {
+			return segment, i, fmt.Errorf("unterminated argument for %q at offset %d", "%"+name, start-1)
+		}
+		segment.arg = pattern[i+1 : i+end]
+		i += end + 1
+	}
+	return segment, i, nil
+}
+
+func parsePatternNumber(pattern string, i int) (int, int) {
+	n := 0
+	for i < len(pattern) && pattern[i] >= '0' && pattern[i] <= '9' {
+		n = n*10 + int(pattern[i]-'0')
+		i++
+	}
+	return n, i
+}
+
+func (f *PatternFormatter) Format(messageData model.MessageData) (string, error) {
+	var b strings.Builder
+	for _, segment := range f.segments {
+		if segment.field == nil {
+			b.WriteString(segment.literal)
+			continue
+		}
+
+		text := segment.field(f, messageData, segment.arg)
+		if segment.maxLength > 0 && utf8.RuneCountInString(text) > segment.maxLength {
+			text = string([]rune(text)[:segment.maxLength])
+		}
+		if padding := segment.width - utf8.RuneCountInString(text); padding > 0 {
+			if segment.leftAlign {
+				text += strings.Repeat(" ", padding)
+			} else {
+				text = strings.Repeat(" ", padding) + text
+			}
+		}
+		b.WriteString(text)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,434 @@
+package pkg
+
+import (
+	"fmt"
+	"omnilogger/model"
+	pkg "omnilogger/pkg"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxSize is the size at which RotatingFileDriver rolls over when RotationOptions.MaxSize is not set.
+const DefaultMaxSize int64 = 100 << 20
+
+// backupTimeLayout names timestamped backups, app-2024-05-01T10-04-05.000000000.log. It sorts in time order.
+const backupTimeLayout = "2006-01-02T15-04-05.000000000"
+
+// compressedSuffix is added to backups once they are compressed.
+const compressedSuffix = ".gz"
+
+// RotationInterval is the period after which RotatingFileDriver starts a new file.
+type RotationInterval string
+
+const (
+	RotateHourly RotationInterval = "hourly" // At the start of every hour.
+	RotateDaily  RotationInterval = "daily"  // At midnight.
+)
+
+// RotationOptions controls when RotatingFileDriver rolls over and which backups it keeps.
+type RotationOptions struct {
+	MaxSize        int64            // Size in bytes at which the file rolls over, DefaultMaxSize when not set.
+	Interval       RotationInterval // Also roll over at the start of every hour or day, in the location of the clock.
+	MaxBackups     int              // Number of rotated files kept, all of them when not set.
+	MaxAge         time.Duration    // Remove backups rotated longer ago than this, none when not set.
+	TimestampNames bool             // Name backups after the rotation time, app-2024-05-01T10-04-05.000000000.log, instead of app.log.1.
+
+	// NamePattern names backups with a Go time layout such as "app-2006-01-02.log", formatted with the
+	// start of the period the file covers, or with the rotation time without Interval. The name is
+	// relative to the directory of the file. A second backup with the same name gets a ".1" suffix.
+	NamePattern string
+
+	Compress bool             // Gzip backups in the background, adding a ".gz" suffix.
+	Now      func() time.Time // Clock deciding rotation times and backup ages, time.Now when not set.
+	OnError  func(err error)  // Receives errors of the background compression, written to stderr when not set.
+}
+
+// RotatingFileDriver appends entries to a file, as JSON unless Formatter is set, and rolls the file
+// over once it would grow past MaxSize or, with Interval, when a new hour or day starts. With numbered
+// names the newest backup is app.log.1, the one before it app.log.2 and so on. An entry is never split
+// across files. Writes and rotations are serialized, so the driver can be shared by every goroutine
+// logging through the same logger.
+type RotatingFileDriver struct {
+	Formatter pkg.Formatter
+
+	mu      sync.Mutex
+	path    string
+	options RotationOptions
+	file    *os.File
+	size    int64
+	period  time.Time // Start of the period the file covers, with Interval.
+	closed  bool
+
+	// Backups being compressed by name, each closed once its compression is done. Only rotations
+	// touching one of these names wait for it.
+	compressing map[string]chan struct{}
+}
+
+// NewRotatingFileDriver opens filePath for appending and rotates it as options say.
+func NewRotatingFileDriver(filePath string, options RotationOptions) (*RotatingFileDriver, error) {
+	if options.MaxSize <= 0 {
+		options.MaxSize = DefaultMaxSize
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	if options.OnError == nil {
+		options.OnError = func(err error) {
+			fmt.Fprintf(os.Stderr, "omnilogger: %v\n", err)
+		}
+	}
+	switch options.Interval {
+	case "", RotateHourly, RotateDaily:
+	default:
+		return nil, fmt.Errorf("unknown rotation interval: %q", options.Interval)
+	}
+	if strings.ContainsRune(options.NamePattern, filepath.Separator) {
+		return nil, fmt.Errorf("name pattern must be a file name: %q", options.NamePattern)
+	}
+
+	d := &RotatingFileDriver{path: filePath, options: options, compressing: map[string]chan struct{}{}}
+	if err := d.open(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *RotatingFileDriver) WriteLog(message string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return os.ErrClosed
+	}
+	line := message + "\n"
+	if d.file != nil && d.size == 0 && d.periodEnded() {
+		// Nothing was logged during the period, so the empty file moves on to the current one.
+		d.period = d.periodStart(d.options.Now())
+	}
+	if d.file != nil && d.size > 0 && (d.size+int64(len(line)) > d.options.MaxSize || d.periodEnded()) {
+		if err := d.rotate(); err != nil {
+			return err
+		}
+	}
+	if d.file == nil {
+		// A failed rotation left no file open, try again instead of dropping every later entry.
+		if err := d.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := d.file.WriteString(line)
+	d.size += int64(n)
+	return err
+}
+
+func (d *RotatingFileDriver) FormatLog(messageData model.MessageData) (string, error) {
+	if d.Formatter == nil {
+		return (&JSONFormatter{}).Format(messageData)
+	}
+	return formatFor(d.Formatter, messageData, nil)
+}
+
+// Rotate rolls the file over now, regardless of its size.
+func (d *RotatingFileDriver) Rotate() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return os.ErrClosed
+	}
+	return d.rotate()
+}
+
+// Close closes the file once the backups being compressed are done. Writes after Close fail with os.ErrClosed.
+func (d *RotatingFileDriver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	for backup := range d.compressing {
+		d.waitCompressed(backup)
+	}
+	if d.file == nil {
+		return nil
+	}
+	err := d.file.Close()
+	d.file = nil
+	return err
+}
+
+func (d *RotatingFileDriver) open() error {
+	file, err := os.OpenFile(d.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	d.file = file
+	d.size = info.Size()
+
+	// A file left over from an earlier period rolls over with the next entry.
+	d.period = d.periodStart(d.options.Now())
+	if d.size > 0 && info.ModTime().Before(d.period) {
+		d.period = d.periodStart(info.ModTime().In(d.period.Location()))
+	}
+	return nil
+}
+
+// periodStart returns the start of the hour or day holding t.
+func (d *RotatingFileDriver) periodStart(t time.Time) time.Time {
+	switch d.options.Interval {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	default:
+		return t
+	}
+}
+
+// periodEnded reports whether the clock has moved past the period of the file.
+func (d *RotatingFileDriver) periodEnded() bool {
+	switch d.options.Interval {
+	case RotateHourly:
+		return !d.options.Now().Before(d.period.Add(time.Hour))
+	case RotateDaily:
+		return !d.options.Now().Before(d.period.AddDate(0, 0, 1))
+	default:
+		return false
+	}
+}
+
+// rotate closes the current file, moves it to a backup name, starts compressing it, removes the
+// backups beyond MaxBackups and MaxAge and opens a new file. d.mu must be held.
+func (d *RotatingFileDriver) rotate() error {
+	if d.file != nil {
+		if err := d.file.Close(); err != nil {
+			return err
+		}
+		d.file = nil
+	}
+
+	now := d.options.Now()
+	backup, err := d.moveToBackup(now)
+	if err == nil {
+		// Backup ages are measured from the rotation time of the clock.
+		err = os.Chtimes(backup, now, now)
+	}
+	if err == nil {
+		err = d.removeOldBackups(now)
+	}
+	if err != nil {
+		return fmt.Errorf("rotate %s: %w", d.path, err)
+	}
+
+	if d.options.Compress {
+		d.forgetCompressed()
+		done := make(chan struct{})
+		d.compressing[backup] = done
+		go func() {
+			defer close(done)
+			if err := compressFile(backup); err != nil {
+				d.options.OnError(fmt.Errorf("compress %s: %w", backup, err))
+			}
+		}()
+	}
+	return d.open()
+}
+
+// waitCompressed waits until the backup named name, if it is being compressed, is done, so it can
+// be renamed or removed without racing with its compression. d.mu must be held.
+func (d *RotatingFileDriver) waitCompressed(name string) {
+	if done, ok := d.compressing[name]; ok {
+		<-done
+		delete(d.compressing, name)
+	}
+}
+
+// forgetCompressed removes the compressions that are done, which nothing may ever wait for
+// when backups are neither shifted nor removed. d.mu must be held.
+func (d *RotatingFileDriver) forgetCompressed() {
+	for name, done := range d.compressing {
+		select {
+		case <-done:
+			delete(d.compressing, name)
+		default:
+		}
+	}
+}
+
+// moveToBackup renames the file to its backup name and returns that name.
+func (d *RotatingFileDriver) moveToBackup(now time.Time) (string, error) {
+	switch {
+	case d.options.NamePattern != "":
+		stamp := now
+		if d.options.Interval != "" {
+			stamp = d.period
+		}
+		backup := uniqueName(filepath.Join(filepath.Dir(d.path), stamp.Format(d.options.NamePattern)))
+		return backup, os.Rename(d.path, backup)
+	case d.options.TimestampNames:
+		backup := uniqueName(d.timestampedName(now))
+		return backup, os.Rename(d.path, backup)
+	default:
+		return d.numberedName(1), d.shiftNumbered()
+	}
+}
+
+// shiftNumbered shifts app.log.N to app.log.N+1, dropping the oldest beyond MaxBackups, and
+// moves the file to app.log.1.
+func (d *RotatingFileDriver) shiftNumbered() error {
+	last := d.options.MaxBackups
+	if last <= 0 {
+		// Keeping every backup, the oldest one moves up to the first free number.
+		for last = 1; backupExists(d.numberedName(last)); last++ {
+		}
+	}
+	for i := 1; i <= last; i++ {
+		d.waitCompressed(d.numberedName(i))
+	}
+	if d.options.MaxBackups > 0 {
+		if err := removeBackup(d.numberedName(last)); err != nil {
+			return err
+		}
+	}
+
+	for i := last; i > 1; i-- {
+		for _, suffix := range []string{"", compressedSuffix} {
+			err := os.Rename(d.numberedName(i-1)+suffix, d.numberedName(i)+suffix)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return os.Rename(d.path, d.numberedName(1))
+}
+
+func (d *RotatingFileDriver) numberedName(n int) string {
+	return d.path + "." + strconv.Itoa(n)
+}
+
+// timestampedName returns app-2024-05-01T10-04-05.000000000.log for app.log.
+func (d *RotatingFileDriver) timestampedName(t time.Time) string {
+	ext := filepath.Ext(d.path)
+	return strings.TrimSuffix(d.path, ext) + "-" + t.Format(backupTimeLayout) + ext
+}
+
+// trailingNumber matches the ".1" suffix of numbered backups and of backups made unique.
+var trailingNumber = regexp.MustCompile(`\.[0-9]+$`)
+
+// isBackup reports whether name, a file in the directory of the log, is one of its backups.
+func (d *RotatingFileDriver) isBackup(name string) bool {
+	base := filepath.Base(d.path)
+	name = strings.TrimSuffix(name, compressedSuffix)
+	if name == base {
+		return false
+	}
+
+	switch {
+	case d.options.NamePattern != "":
+		if _, err := time.Parse(d.options.NamePattern, name); err == nil {
+			return true
+		}
+		_, err := time.Parse(d.options.NamePattern, trailingNumber.ReplaceAllString(name, ""))
+		return err == nil
+	case d.options.TimestampNames:
+		ext := filepath.Ext(base)
+		prefix := strings.TrimSuffix(base, ext) + "-"
+		stamp := strings.TrimSuffix(trailingNumber.ReplaceAllString(name, ""), ext)
+		if !strings.HasPrefix(stamp, prefix) {
+			return false
+		}
+		_, err := time.Parse(backupTimeLayout, strings.TrimPrefix(stamp, prefix))
+		return err == nil
+	default:
+		return strings.HasPrefix(name, base) && trailingNumber.MatchString(name[len(base):]) &&
+			!strings.Contains(name[len(base)+1:], ".")
+	}
+}
+
+// removeOldBackups removes the backups beyond MaxBackups, oldest first, and those older than MaxAge.
+func (d *RotatingFileDriver) removeOldBackups(now time.Time) error {
+	if d.options.MaxBackups <= 0 && d.options.MaxAge <= 0 {
+		return nil
+	}
+
+	dir := filepath.Dir(d.path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	type backupFile struct {
+		name    string // Path without the compressed suffix.
+		modTime time.Time
+	}
+	var backups []backupFile
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || !d.isBackup(entry.Name()) {
+			continue
+		}
+		// A backup being compressed can be listed both plain and compressed.
+		name := filepath.Join(dir, strings.TrimSuffix(entry.Name(), compressedSuffix))
+		if seen[name] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		seen[name] = true
+		backups = append(backups, backupFile{name, info.ModTime()})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].modTime.After(backups[j].modTime)
+	})
+
+	for i, backup := range backups {
+		tooMany := d.options.MaxBackups > 0 && i >= d.options.MaxBackups
+		tooOld := d.options.MaxAge > 0 && now.Sub(backup.modTime) > d.options.MaxAge
+		if tooMany || tooOld {
+			d.waitCompressed(backup.name)
+			if err := removeBackup(backup.name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// uniqueName adds a ".1", ".2"... suffix to name while a backup by that name exists.
+func uniqueName(name string) string {
+	unique := name
+	for i := 1; backupExists(unique); i++ {
+		unique = name + "." + strconv.Itoa(i)
+	}
+	return unique
+}
+
+// backupExists reports whether a backup named name exists, compressed or not.
+func backupExists(name string) bool {
+	for _, path := range []string{name, name + compressedSuffix} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// removeBackup removes the backup named name, compressed or not.
+func removeBackup(name string) error {
+	for _, path := range []string{name, name + compressedSuffix} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package pkg
+
+import (
+	"context"
+	"log/slog"
+	"omnilogger/config"
+	"omnilogger/model"
+	"sort"
+	"time"
+)
+
+// SlogDriver forwards entries to an existing slog.Handler.
+type SlogDriver struct {
+	handler slog.Handler
+}
+
+func NewSlogDriver(handler slog.Handler) *SlogDriver {
+	return &SlogDriver{handler: handler}
+}
+
+// SlogLevel maps an OmniLogger level to a slog level by its severity, so DEBUG to ERROR
+// match their slog counterparts, FATAL becomes slog.LevelError+4 and custom levels fall in between.
+func SlogLevel(level string) slog.Level {
+	severity, ok := config.SeverityOf(config.LogLevel(level))
+	if !ok {
+		return slog.LevelInfo
+	}
+	return slog.Level((int(severity) - 20) * 4 / 10)
+}
+
+// WriteEntry converts the entry into a slog.Record and passes it to the handler.
+func (d *SlogDriver) WriteEntry(messageData model.MessageData) error {
+	ctx := context.Background()
+	level := SlogLevel(messageData.Level)
+	if !d.handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, messageData.Timestamp)
+	if err != nil {
+		timestamp = time.Now()
+	}
+
+	record := slog.NewRecord(timestamp, level, messageData.Message, 0)
+	if messageData.Context != nil {
+		if messageData.Context.TransactionID != "" {
+			record.AddAttrs(slog.String("transaction_id", messageData.Context.TransactionID))
+		}
+		if messageData.Context.UserID != "" {
+			record.AddAttrs(slog.String("user_id", messageData.Context.UserID))
+		}
+		keys := make([]string, 0, len(messageData.Context.MetaData))
+		for key := range messageData.Context.MetaData {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			record.AddAttrs(slog.Any(key, messageData.Context.MetaData[key]))
+		}
+	}
+	record.AddAttrs(slog.String("stack_trace", messageData.Caller.String()))
+	if len(messageData.StackTrace) > 0 {
+		record.AddAttrs(slog.Any("stack", messageData.StackTrace))
+	}
+	if messageData.Goroutines != "" {
+		record.AddAttrs(slog.String("goroutines", messageData.Goroutines))
+	}
+	if messageData.Error != nil {
+		record.AddAttrs(slog.Any("error", model.DescribeError(messageData.Error)))
+	}
+
+	return d.handler.Handle(ctx, record)
+}
+
+// WriteLog forwards a preformatted message as an INFO record.
+func (d *SlogDriver) WriteLog(message string) error {
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, message, 0)
+	return d.handler.Handle(context.Background(), record)
+}
+
+func (d *SlogDriver) FormatLog(messageData model.MessageData) (string, error) {
+	return messageData.Message, nil
+}
@@ -0,0 +1,383 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"omnilogger/config"
+	"omnilogger/model"
+	pkg "omnilogger/pkg"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyslogFormat is the message format of SyslogDriver.
+type SyslogFormat string
+
+const (
+	SyslogRFC5424 SyslogFormat = "rfc5424" // The current syslog protocol, with structured data.
+	SyslogRFC3164 SyslogFormat = "rfc3164" // The BSD format, understood by every daemon.
+)
+
+// SyslogFacility is the facility part of the syslog priority.
+type SyslogFacility int
+
+const (
+	FacilityKern SyslogFacility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+	_
+	_
+	_
+	_
+	FacilityLocal0
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// SyslogSeverity is the severity part of the syslog priority.
+type SyslogSeverity int
+
+const (
+	SeverityEmergency SyslogSeverity = iota
+	SeverityAlert
+	SeverityCritical
+	SeverityError
+	SeverityWarning
+	SeverityNotice
+	SeverityInfo
+	SeverityDebug
+)
+
+// DefaultSDID is the structured data ID holding the entry context. 32473 is the enterprise number
+// reserved for documentation, replace it with your own through SyslogOptions.SDID.
+const DefaultSDID = "omnilogger@32473"
+
+// localSyslogPaths are the sockets of the local syslog daemon on Linux, macOS and the BSDs.
+var localSyslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogOptions controls where SyslogDriver sends entries and how it formats them.
+type SyslogOptions struct {
+	Network  string         // "unixgram", "udp" or "tcp", the local daemon over "unixgram" when not set.
+	Address  string         // Socket path or host:port, the first of /dev/log, /var/run/syslog and /var/run/log when not set.
+	Format   SyslogFormat   // SyslogRFC5424 when not set.
+	Facility SyslogFacility // FacilityUser when not set, FacilityKern is reserved for the kernel.
+	AppName  string         // The APP-NAME or tag, the program name when not set.
+	Hostname string         // The HOSTNAME, os.Hostname when not set. Left out of RFC 3164 messages sent to a local socket.
+	SDID     string         // Structured data ID of the context, DefaultSDID when not set.
+
+	// Severities overrides the severity of levels. Other levels map by severity: FATAL and above
+	// to critical, ERROR to error, WARN to warning, INFO to info and anything lower to debug.
+	Severities map[config.LogLevel]SyslogSeverity
+
+	Formatter pkg.Formatter // Renders the MSG part, the entry message when not set.
+}
+
+// SyslogDriver sends entries to a syslog daemon, over a unix datagram socket, UDP, or TCP with
+// octet-counted framing (RFC 6587). RFC 5424 messages carry the entry context as structured data:
+//
+//	<12>1 2024-05-01T10:04:05Z web-1 orders 4242 - [omnilogger@32473 user_id="user456" order_id="7"] order saved
+//
+// RFC 3164 messages append the context to the message as key=value pairs. A broken connection
+// is dialed again on the next entry.
+type SyslogDriver struct {
+	options SyslogOptions
+	pid     string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogDriver connects to the syslog daemon described by options.
+func NewSyslogDriver(options SyslogOptions) (*SyslogDriver, error) {
+	switch options.Network {
+	case "":
+		options.Network = "unixgram"
+	case "unixgram", "udp", "tcp":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network: %q", options.Network)
+	}
+	switch options.Format {
+	case "":
+		options.Format = SyslogRFC5424
+	case SyslogRFC5424, SyslogRFC3164:
+	default:
+		return nil, fmt.Errorf("unknown syslog format: %q", options.Format)
+	}
+	if options.Facility == FacilityKern {
+		options.Facility = FacilityUser
+	}
+	if options.Facility < FacilityKern || options.Facility > FacilityLocal7 {
+		return nil, fmt.Errorf("invalid syslog facility: %d", options.Facility)
+	}
+	if options.AppName == "" {
+		options.AppName = filepath.Base(os.Args[0])
+	}
+	if options.Hostname == "" {
+		options.Hostname, _ = os.Hostname()
+	}
+	if options.SDID == "" {
+		options.SDID = DefaultSDID
+	}
+
+	d := &SyslogDriver{options: options, pid: strconv.Itoa(os.Getpid())}
+	if err := d.dial(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *SyslogDriver) dial() error {
+	if d.options.Address != "" {
+		conn, err := net.Dial(d.options.Network, d.options.Address)
+		if err != nil {
+			return err
+		}
+		d.conn = conn
+		return nil
+	}
+	if d.options.Network != "unixgram" {
+		return fmt.Errorf("syslog over %s needs an address", d.options.Network)
+	}
+
+	var errs []error
+	for _, path := range localSyslogPaths {
+		conn, err := net.Dial("unixgram", path)
+		if err == nil {
+			d.conn = conn
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("no local syslog daemon: %w", errors.Join(errs...))
+}
+
+// WriteLog sends a message returned by FormatLog, framing it for TCP.
+func (d *SyslogDriver) WriteLog(message string) error {
+	frame := message
+	if d.options.Network == "tcp" {
+		frame = strconv.Itoa(len(message)) + " " + message
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conn != nil {
+		if _, err := d.conn.Write([]byte(frame)); err == nil {
+			return nil
+		}
+		d.conn.Close()
+		d.conn = nil
+	}
+	if err := d.dial(); err != nil {
+		return err
+	}
+	_, err := d.conn.Write([]byte(frame))
+	return err
+}
+
+// FormatLog renders the entry as a complete syslog message in the configured format.
+func (d *SyslogDriver) FormatLog(messageData model.MessageData) (string, error) {
+	message := messageData.Message
+	if d.options.Formatter != nil {
+		formatted, err := formatFor(d.options.Formatter, messageData, nil)
+		if err != nil {
+			return "", err
+		}
+		message = formatted
+	}
+
+	priority := int(d.options.Facility)*8 + int(d.severity(config.LogLevel(messageData.Level)))
+	if d.options.Format == SyslogRFC3164 {
+		return d.formatRFC3164(priority, messageData, message), nil
+	}
+	return d.formatRFC5424(priority, messageData, message), nil
+}
+
+// Close closes the connection to the daemon.
+func (d *SyslogDriver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conn == nil {
+		return nil
+	}
+	err := d.conn.Close()
+	d.conn = nil
+	return err
+}
+
+func (d *SyslogDriver) severity(level config.LogLevel) SyslogSeverity {
+	if severity, ok := d.options.Severities[level.Normalize()]; ok {
+		return severity
+	}
+	levelSeverity, ok := config.SeverityOf(level)
+	if !ok {
+		return SeverityInfo
+	}
+	severities := []struct {
+		level    config.LogLevel
+		severity SyslogSeverity
+	}{
+		{config.FATAL, SeverityCritical},
+		{config.ERROR, SeverityError},
+		{config.WARN, SeverityWarning},
+		{config.INFO, SeverityInfo},
+	}
+	for _, candidate := range severities {
+		if threshold, _ := config.SeverityOf(candidate.level); levelSeverity >= threshold {
+			return candidate.severity
+		}
+	}
+	return SeverityDebug
+}
+
+// formatRFC5424 renders <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG.
+func (d *SyslogDriver) formatRFC5424(priority int, messageData model.MessageData, message string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s - ",
+		priority,
+		syslogHeaderField(messageData.Timestamp, 0),
+		syslogHeaderField(d.options.Hostname, 255),
+		syslogHeaderField(d.options.AppName, 48),
+		syslogHeaderField(d.pid, 128),
+	)
+
+	params := syslogParams(messageData)
+	if len(params) == 0 {
+		b.WriteByte('-')
+	} else {
+		b.WriteByte('[')
+		b.WriteString(d.options.SDID)
+		for _, param := range params {
+			b.WriteByte(' ')
+			b.WriteString(sdParamName(param[0]))
+			b.WriteString(`="`)
+			b.WriteString(sdParamValue(param[1]))
+			b.WriteByte('"')
+		}
+		b.WriteByte(']')
+	}
+
+	if message != "" {
+		b.WriteByte(' ')
+		b.WriteString(message)
+	}
+	return b.String()
+}
+
+// formatRFC3164 renders <PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG, leaving out the hostname
+// on a local socket as the C library does.
+func (d *SyslogDriver) formatRFC3164(priority int, messageData model.MessageData, message string) string {
+	timestamp, err := time.Parse(time.RFC3339, messageData.Timestamp)
+	if err != nil {
+		timestamp = time.Now()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>%s ", priority, timestamp.Format(time.Stamp))
+	if d.options.Network != "unixgram" {
+		b.WriteString(syslogHeaderField(d.options.Hostname, 255))
+		b.WriteByte(' ')
+	}
+	fmt.Fprintf(&b, "%s[%s]: %s", syslogHeaderField(d.options.AppName, 32), d.pid, message)
+	for _, param := range syslogParams(messageData) {
+		writeLogfmtPair(&b, param[0], param[1])
+	}
+	return b.String()
+}
+
+// syslogParams returns the context and error of the entry as name/value pairs: transaction_id and
+// user_id first, then the metadata sorted by key with nested maps flattened into dotted names.
+func syslogParams(messageData model.MessageData) [][2]string {
+	var params [][2]string
+	if ctx := messageData.Context; ctx != nil {
+		if ctx.TransactionID != "" {
+			params = append(params, [2]string{"transaction_id", ctx.TransactionID})
+		}
+		if ctx.UserID != "" {
+			params = append(params, [2]string{"user_id", ctx.UserID})
+		}
+		for _, key := range ctx.SortedKeys() {
+			params = appendSyslogParam(params, key, ctx.MetaData[key])
+		}
+	}
+	if messageData.Error != nil {
+		params = append(params, [2]string{"error", messageData.Error.Error()})
+	}
+	return params
+}
+
+func appendSyslogParam(params [][2]string, name string, value interface{}) [][2]string {
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		return append(params, [2]string{name, logfmtValue(value)})
+	}
+	keys := make([]string, 0, len(nested))
+	for key := range nested {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		params = appendSyslogParam(params, name+"."+key, nested[key])
+	}
+	return params
+}
+
+// syslogHeaderField returns value limited to printable ASCII without spaces and to maxLength
+// characters when maxLength is positive, or the nil value "-" when it is empty.
+func syslogHeaderField(value string, maxLength int) string {
+	field := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value)
+	if maxLength > 0 && len(field) > maxLength {
+		field = field[:maxLength]
+	}
+	if field == "" {
+		return "-"
+	}
+	return field
+}
+
+// sdParamName returns name as an RFC 5424 PARAM-NAME: at most 32 printable ASCII characters
+// other than '=', space, ']' and '"'.
+func sdParamName(name string) string {
+	param := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(param) > 32 {
+		param = param[:32]
+	}
+	if param == "" {
+		return "_"
+	}
+	return param
+}
+
+// sdParamValue escapes '"', '\' and ']' in an RFC 5424 PARAM-VALUE.
+func sdParamValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
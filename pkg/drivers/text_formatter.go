@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"fmt"
+	"omnilogger/config"
+	"omnilogger/model"
+	"strings"
+)
+
+// TextFormatter renders entries in the single-line layout of CLIDriver, followed by the
+// error and stack trace on the lines below.
+type TextFormatter struct {
+	Order config.FieldOrder // Field order, level, timestamp, IDs, metadata, caller and message when not set.
+}
+
+var textFieldOrder = []string{
+	config.FieldLevel,
+	config.FieldTimestamp,
+	config.FieldTransactionID,
+	config.FieldUserID,
+	config.FieldMetadata,
+	config.FieldCaller,
+	config.FieldMessage,
+}
+
+func (f *TextFormatter) Format(messageData model.MessageData) (string, error) {
+	var b strings.Builder
+	ctx := messageData.Context
+
+	for _, field := range fieldSequence(f.Order, textFieldOrder) {
+		switch field {
+		case config.FieldLevel:
+			fmt.Fprintf(&b, "[%s] ", messageData.Level)
+		case config.FieldTimestamp:
+			fmt.Fprintf(&b, "timestamp: %s ", messageData.Timestamp)
+		case config.FieldTransactionID:
+			if ctx != nil && ctx.TransactionID != "" {
+				fmt.Fprintf(&b, "transaction_id: %s ", ctx.TransactionID)
+			}
+		case config.FieldUserID:
+			if ctx != nil && ctx.UserID != "" {
+				fmt.Fprintf(&b, "user_id: %s ", ctx.UserID)
+			}
+		case config.FieldMetadata:
+			for _, key := range metadataKeys(ctx, f.Order.Metadata) {
+				fmt.Fprintf(&b, "%s: %v ", key, ctx.MetaData[key])
+			}
+		case config.FieldCaller:
+			fmt.Fprintf(&b, " trace: %s ", messageData.Caller)
+		case config.FieldMessage:
+			fmt.Fprintf(&b, " msg : %s ", messageData.Message)
+		}
+	}
+
+	return b.String() + formatError(messageData.Error) + formatStack(messageData), nil
+}
+
+// formatError renders the error on the lines below the entry, with every wrapped error
+// and errors.Join branch indented under the error wrapping it.
+func formatError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return formatErrorInfo(model.DescribeError(err), "error: ", "")
+}
+
+func formatErrorInfo(info *model.ErrorInfo, label string, indent string) string {
+	text := fmt.Sprintf("\n%s%s%s (%s)", indent, label, info.Message, info.Type)
+	for _, frame := range info.StackTrace {
+		text += fmt.Sprintf("\n%s  %s\n%s  \t%s:%d", indent, frame.Function, indent, frame.File, frame.Line)
+	}
+	if info.Cause != nil {
+		text += formatErrorInfo(info.Cause, "caused by: ", indent+"\t")
+	}
+	for i := range info.Errors {
+		text += formatErrorInfo(&info.Errors[i], fmt.Sprintf("[%d] ", i), indent+"\t")
+	}
+	return text
+}
+
+// formatStack renders the stack trace and goroutine dump on the lines below the entry,
+// in the layout of a Go panic.
+func formatStack(messageData model.MessageData) string {
+	var stack string
+	if len(messageData.StackTrace) > 0 {
+		stack += "\nstack trace:"
+		for _, frame := range messageData.StackTrace {
+			stack += fmt.Sprintf("\n%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+		}
+	}
+	if messageData.Goroutines != "" {
+		stack += "\n" + messageData.Goroutines
+	}
+	return stack
+}
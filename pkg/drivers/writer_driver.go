@@ -0,0 +1,46 @@
+package pkg
+
+import (
+	"io"
+	"omnilogger/model"
+	pkg "omnilogger/pkg"
+	"os"
+	"sync"
+)
+
+// WriterDriver writes entries to any io.Writer, such as a socket, one per line.
+// It uses TextFormatter unless Formatter is set.
+type WriterDriver struct {
+	Formatter pkg.Formatter
+
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+// NewWriterDriver returns a driver writing to w with formatter.
+func NewWriterDriver(w io.Writer, formatter pkg.Formatter) *WriterDriver {
+	return &WriterDriver{Formatter: formatter, writer: w}
+}
+
+func (d *WriterDriver) WriteLog(message string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := io.WriteString(d.writer, message+"\n")
+	return err
+}
+
+func (d *WriterDriver) FormatLog(messageData model.MessageData) (string, error) {
+	if d.Formatter == nil {
+		return (&TextFormatter{}).Format(messageData)
+	}
+	file, _ := d.writer.(*os.File)
+	return formatFor(d.Formatter, messageData, file)
+}
+
+// Close closes the writer when it implements io.Closer.
+func (d *WriterDriver) Close() error {
+	if closer, ok := d.writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
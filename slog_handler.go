@@ -0,0 +1,139 @@
+package omnilogger
+
+import (
+	"context"
+	"log/slog"
+	"omnilogger/config"
+)
+
+// SlogHandler is a slog.Handler that sends records through an OmniLogger and its drivers.
+// Attributes become MetaData fields and groups become nested maps.
+type SlogHandler struct {
+	logger *OmniLogger
+	groups []string // Open groups, attributes are nested under them.
+}
+
+// NewSlogHandler returns a slog.Handler backed by logger.
+//
+//	slog.SetDefault(slog.New(omnilogger.NewSlogHandler(logger)))
+func NewSlogHandler(logger *OmniLogger) *SlogHandler {
+	return &SlogHandler{logger: logger}
+}
+
+// LevelFromSlog maps a slog level to the OmniLogger level covering it.
+// Records above slog.LevelError map to ERROR, so slog never terminates the process.
+func LevelFromSlog(level slog.Level) config.LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return DEBUG
+	case level < slog.LevelWarn:
+		return INFO
+	case level < slog.LevelError:
+		return WARN
+	default:
+		return ERROR
+	}
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.load().config.Enabled(LevelFromSlog(level))
+}
+
+func (h *SlogHandler) Handle(_ context.Context, record slog.Record) error {
+	logger := h.logger
+	if record.NumAttrs() > 0 {
+		attrs := make([]slog.Attr, 0, record.NumAttrs())
+		record.Attrs(func(attr slog.Attr) bool {
+			attrs = append(attrs, attr)
+			return true
+		})
+		logger = h.withAttrs(attrs)
+	}
+	logger.logWritter(LevelFromSlog(record.Level), record.Message, record.PC, record.Time)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &SlogHandler{logger: h.withAttrs(attrs), groups: h.groups}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	groups := append(append([]string(nil), h.groups...), name)
+	return &SlogHandler{logger: h.logger, groups: groups}
+}
+
+// withAttrs returns a child logger holding attrs nested under the open groups.
+func (h *SlogHandler) withAttrs(attrs []slog.Attr) *OmniLogger {
+	fields := map[string]interface{}{}
+	addAttrs(fields, attrs)
+	if len(fields) == 0 {
+		return h.logger
+	}
+
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		fields = map[string]interface{}{h.groups[i]: fields}
+	}
+
+	// Merge nested groups with the ones the parent already holds instead of replacing them.
+	var existing map[string]interface{}
+	if h.logger.context != nil {
+		existing = h.logger.context.MetaData
+	}
+	for key, value := range fields {
+		fields[key] = mergeGroupValue(existing[key], value)
+	}
+	return h.logger.WithFields(fields)
+}
+
+// addAttrs converts attrs into fields, following the slog.Handler rules for empty attributes and groups.
+func addAttrs(fields map[string]interface{}, attrs []slog.Attr) {
+	for _, attr := range attrs {
+		attr.Value = attr.Value.Resolve()
+		if attr.Equal(slog.Attr{}) {
+			continue
+		}
+		if attr.Value.Kind() != slog.KindGroup {
+			fields[attr.Key] = attr.Value.Any()
+			continue
+		}
+
+		groupAttrs := attr.Value.Group()
+		if len(groupAttrs) == 0 {
+			continue
+		}
+		if attr.Key == "" {
+			addAttrs(fields, groupAttrs) // Groups without a key are inlined.
+			continue
+		}
+		group := map[string]interface{}{}
+		addAttrs(group, groupAttrs)
+		fields[attr.Key] = mergeGroupValue(fields[attr.Key], group)
+	}
+}
+
+// mergeGroupValue merges two group maps without modifying either, any other value replaces the existing one.
+func mergeGroupValue(existing, value interface{}) interface{} {
+	existingGroup, ok := existing.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	group, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+
+	merged := make(map[string]interface{}, len(existingGroup)+len(group))
+	for key, existingValue := range existingGroup {
+		merged[key] = existingValue
+	}
+	for key, groupValue := range group {
+		merged[key] = mergeGroupValue(existingGroup[key], groupValue)
+	}
+	return merged
+}
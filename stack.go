@@ -0,0 +1,67 @@
+package omnilogger
+
+import (
+	"omnilogger/config"
+	"omnilogger/model"
+	"runtime"
+	"strings"
+)
+
+const (
+	// stackSlack leaves room for the frames between the caller and captureStack, which are trimmed off.
+	stackSlack = 16
+	// maxGoroutineDump bounds the size of the goroutine dump attached to terminal entries.
+	maxGoroutineDump = 64 << 20
+)
+
+// captureStack returns the call stack starting at the frame identified by pc, applying the
+// depth and filters of cfg. skip counts frames above the function calling captureStack, as for callerPC.
+func captureStack(cfg config.StackTraceConfig, pc uintptr, skip int) []model.Frame {
+	depth := cfg.MaxDepth()
+	pcs := make([]uintptr, depth+stackSlack)
+	pcs = pcs[:runtime.Callers(skip+2, pcs)]
+
+	// Drop the frames of the logging entry point, so the stack starts at the caller.
+	for i, candidate := range pcs {
+		if candidate == pc {
+			pcs = pcs[i:]
+			break
+		}
+	}
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	stack := make([]model.Frame, 0, depth)
+	frames := runtime.CallersFrames(pcs)
+	for len(stack) < depth {
+		frame, more := frames.Next()
+		if !filterFrame(cfg, frame.Function) {
+			stack = append(stack, model.Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// filterFrame reports whether the filters of cfg leave out a frame of function.
+func filterFrame(cfg config.StackTraceConfig, function string) bool {
+	if cfg.FilterRuntime && strings.HasPrefix(function, "runtime.") {
+		return true
+	}
+	return cfg.FilterOmnilogger && strings.HasPrefix(function, "omnilogger.")
+}
+
+// goroutineDump returns the stacks of all goroutines, as printed by an unrecovered panic.
+func goroutineDump() string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxGoroutineDump {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
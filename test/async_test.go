@@ -0,0 +1,177 @@
+package test
+
+import (
+	"context"
+	"omnilogger"
+	"omnilogger/config"
+	"omnilogger/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+// BlockingDriver holds every write until release is closed.
+type BlockingDriver struct {
+	mu       sync.Mutex
+	messages []string
+	started  chan struct{}
+	release  chan struct{}
+	once     sync.Once
+}
+
+func NewBlockingDriver() *BlockingDriver {
+	return &BlockingDriver{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (d *BlockingDriver) WriteLog(message string) error {
+	d.once.Do(func() { close(d.started) })
+	<-d.release
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.messages = append(d.messages, message)
+	return nil
+}
+
+func (d *BlockingDriver) FormatLog(messageData model.MessageData) (string, error) {
+	return messageData.Message, nil
+}
+
+func (d *BlockingDriver) Messages() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.messages...)
+}
+
+func waitForMessages(t *testing.T, driver *BlockingDriver, count int) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if messages := driver.Messages(); len(messages) >= count {
+			return messages
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d messages, got %v", count, driver.Messages())
+	return nil
+}
+
+func asyncConfig(overflow config.OverflowPolicy) config.Config {
+	return config.Config{
+		LogLevels: map[config.LogLevel]bool{omnilogger.INFO: true},
+		Async:     config.AsyncConfig{Enabled: true, QueueSize: 1, Overflow: overflow},
+	}
+}
+
+func TestAsyncDropNewest(t *testing.T) {
+	driver := NewBlockingDriver()
+	logger := omnilogger.NewOmniLogger(asyncConfig(config.OverflowDropNewest), nil, driver)
+
+	logger.Info("first")
+	<-driver.started // The worker is now busy with the first entry.
+	logger.Info("second")
+	logger.Info("third")
+
+	if dropped := logger.Dropped(); dropped != 1 {
+		t.Errorf("expected 1 dropped entry, got %d", dropped)
+	}
+
+	close(driver.release)
+	messages := waitForMessages(t, driver, 2)
+	if messages[0] != "first" || messages[1] != "second" {
+		t.Errorf("expected [first second], got %v", messages)
+	}
+}
+
+func TestAsyncDropOldest(t *testing.T) {
+	driver := NewBlockingDriver()
+	logger := omnilogger.NewOmniLogger(asyncConfig(config.OverflowDropOldest), nil, driver)
+
+	logger.Info("first")
+	<-driver.started
+	logger.Info("second")
+	logger.Info("third")
+
+	stats := logger.Stats()
+	if len(stats) != 1 || stats[0].Dropped != 1 || stats[0].Pending != 1 {
+		t.Errorf("expected one queue with 1 pending and 1 dropped entry, got %+v", stats)
+	}
+
+	close(driver.release)
+	messages := waitForMessages(t, driver, 2)
+	if messages[0] != "first" || messages[1] != "third" {
+		t.Errorf("expected [first third], got %v", messages)
+	}
+}
+
+func TestAsyncDropOldestKeepsFlushMarkers(t *testing.T) {
+	driver := NewBlockingDriver()
+	logger := omnilogger.NewOmniLogger(asyncConfig(config.OverflowDropOldest), nil, driver)
+
+	logger.Info("first")
+	<-driver.started
+	flushed := make(chan error, 1)
+	go func() { flushed <- logger.Flush(context.Background()) }()
+	for logger.Stats()[0].Pending != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	// The queue is full with the flush marker, so this entry pushes it out.
+	logger.Info("second")
+
+	select {
+	case err := <-flushed:
+		t.Fatalf("expected Flush to wait for the write in progress, it returned %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(driver.release)
+	select {
+	case err := <-flushed:
+		if err != nil {
+			t.Errorf("expected Flush to succeed, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected Flush to return once the write finished")
+	}
+	if messages := driver.Messages(); len(messages) == 0 || messages[0] != "first" {
+		t.Errorf("expected the first entry to be written before Flush returned, got %v", messages)
+	}
+}
+
+func TestAsyncSetConfigResizesQueue(t *testing.T) {
+	driver := NewBlockingDriver()
+	logger := omnilogger.NewOmniLogger(asyncConfig(config.OverflowDropNewest), nil, driver)
+
+	cfg := asyncConfig(config.OverflowDropNewest)
+	cfg.Async.QueueSize = 3
+	logger.SetConfig(cfg)
+
+	logger.Info("first")
+	<-driver.started
+	for i := 0; i < 3; i++ {
+		logger.Info("queued")
+	}
+
+	stats := logger.Stats()
+	if len(stats) != 1 || stats[0].Pending != 3 || stats[0].Dropped != 0 {
+		t.Errorf("expected the resized queue to hold 3 entries, got %+v", stats)
+	}
+	close(driver.release)
+	waitForMessages(t, driver, 4)
+}
+
+func TestAsyncDoesNotWaitForSlowDriver(t *testing.T) {
+	driver := NewBlockingDriver()
+	cfg := asyncConfig(config.OverflowBlock)
+	cfg.Async.QueueSize = 10
+	logger := omnilogger.NewOmniLogger(cfg, nil, driver)
+
+	for i := 0; i < 5; i++ {
+		logger.Info("queued")
+	}
+
+	close(driver.release)
+	waitForMessages(t, driver, 5)
+	if dropped := logger.Dropped(); dropped != 0 {
+		t.Errorf("expected no dropped entries with the block policy, got %d", dropped)
+	}
+}
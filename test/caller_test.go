@@ -0,0 +1,62 @@
+package test
+
+import (
+	"log"
+	"log/slog"
+	"omnilogger"
+	"omnilogger/config"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// nextLine returns the line following the call, where the entry under test is logged.
+func nextLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line + 1
+}
+
+// wrappedInfo mimics a wrapper library that logs on behalf of its caller.
+func wrappedInfo(logger *omnilogger.OmniLogger, message string) {
+	logger.AddCallerSkip(1).Info(message)
+}
+
+func TestCallerForEveryEntryPoint(t *testing.T) {
+	driver := &RecordingDriver{}
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.DEBUG}, nil, driver)
+	defer omnilogger.SetDefault(logger)()
+
+	var lines []int
+	lines = append(lines, nextLine())
+	logger.Info("method")
+	lines = append(lines, nextLine())
+	logger.Warnf("formatted %s", "method")
+	lines = append(lines, nextLine())
+	logger.With("key", "value").Log(omnilogger.ERROR, "child")
+	lines = append(lines, nextLine())
+	omnilogger.Info("package-level")
+	lines = append(lines, nextLine())
+	omnilogger.Debugf("formatted %s", "package-level")
+	lines = append(lines, nextLine())
+	wrappedInfo(logger, "wrapped")
+	lines = append(lines, nextLine())
+	slog.New(omnilogger.NewSlogHandler(logger)).Info("slog")
+	restore := omnilogger.RedirectStdLog(logger, omnilogger.INFO)
+	lines = append(lines, nextLine())
+	log.Print("standard log")
+	restore()
+
+	entries := driver.Entries()
+	if len(entries) != len(lines) {
+		t.Fatalf("expected %d entries, got %d", len(lines), len(entries))
+	}
+	for i, entry := range entries {
+		caller := entry.Caller
+		if !strings.HasSuffix(caller.File, "caller_test.go") || caller.Line != lines[i] {
+			t.Errorf("%s: expected caller_test.go:%d, got %s", entry.Message, lines[i], caller)
+		}
+		if !strings.HasSuffix(caller.Function, "TestCallerForEveryEntryPoint") {
+			t.Errorf("%s: expected the test function, got %q", entry.Message, caller.Function)
+		}
+	}
+}
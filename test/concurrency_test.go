@@ -0,0 +1,127 @@
+package test
+
+import (
+	"context"
+	"omnilogger"
+	"omnilogger/config"
+	"omnilogger/model"
+	"sync"
+	"testing"
+)
+
+func TestSetDefaultRestores(t *testing.T) {
+	driver := &RecordingDriver{}
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.DEBUG}, nil, driver)
+
+	previous := omnilogger.Default()
+	restore := omnilogger.SetDefault(logger)
+	omnilogger.Info("through the default logger")
+	restore()
+
+	if omnilogger.Default() != previous {
+		t.Error("expected restore to bring back the previous default logger")
+	}
+	if len(driver.Entries()) != 1 {
+		t.Errorf("expected 1 entry, got %d", len(driver.Entries()))
+	}
+}
+
+func TestRemoveAndReplaceDrivers(t *testing.T) {
+	first, second, third := &MockDriver{}, &MockDriver{}, &MockDriver{}
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.DEBUG}, nil,
+		omnilogger.WithDriverOptions(first, omnilogger.DriverOptions{Name: "first"}),
+		omnilogger.WithDriverOptions(second, omnilogger.DriverOptions{Name: "second"}),
+	)
+	child := logger.With("key", "value")
+
+	if !logger.RemoveDriver("first") {
+		t.Fatal("expected the first driver to be removed")
+	}
+	if logger.RemoveDriver("missing") {
+		t.Error("expected removing an unknown driver to report false")
+	}
+	child.Info("after remove")
+
+	logger.ReplaceDrivers(third)
+	child.Info("after replace")
+
+	if len(first.messages) != 0 || len(second.messages) != 1 || len(third.messages) != 1 {
+		t.Errorf("expected 0/1/1 messages, got %d/%d/%d", len(first.messages), len(second.messages), len(third.messages))
+	}
+}
+
+func TestCustomLevelDoesNotLeakToParent(t *testing.T) {
+	const trace config.LogLevel = "TRACE"
+	mockDriver := &MockDriver{}
+	parent := omnilogger.NewOmniLogger(config.Config{LogLevels: map[config.LogLevel]bool{}}, nil, mockDriver)
+	restore := omnilogger.SetDefault(parent)
+	defer restore()
+
+	child, _ := omnilogger.GetOmniLoggerWithContext(model.Context{})
+	child.AddCustomLogLevel(trace, true)
+
+	parent.Log(trace, "parent")
+	child.Log(trace, "child")
+	if len(mockDriver.messages) != 1 || mockDriver.messages[0] != "child" {
+		t.Errorf("expected only the child to log TRACE, got %v", mockDriver.messages)
+	}
+}
+
+func TestConcurrentLoggingAndDriverChanges(t *testing.T) {
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.DEBUG}, nil, &RecordingDriver{})
+	restore := omnilogger.SetDefault(logger)
+	defer restore()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				omnilogger.Info("concurrent")
+				omnilogger.With("j", j).Debug("child")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				omnilogger.AddDriver(omnilogger.WithDriverOptions(&RecordingDriver{}, omnilogger.DriverOptions{Name: "extra"}))
+				omnilogger.RemoveDriver("extra")
+				omnilogger.AddConfig(config.Config{MinLevel: omnilogger.DEBUG})
+				child, _ := omnilogger.GetOmniLoggerWithContext(model.Context{})
+				child.AddCustomLogLevel("TRACE", true)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAddDriverDuringAsyncToggle(t *testing.T) {
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.DEBUG}, nil)
+	asyncCfg := config.Config{MinLevel: omnilogger.DEBUG, Async: config.AsyncConfig{Enabled: true}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				logger.AddDriver(&RecordingDriver{})
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			logger.SetConfig(config.Config{MinLevel: omnilogger.DEBUG})
+			logger.SetConfig(asyncCfg)
+		}
+	}()
+	wg.Wait()
+
+	if stats := logger.Stats(); len(stats) != 200 {
+		t.Errorf("expected every driver to run in async mode, got %d of 200", len(stats))
+	}
+	logger.Shutdown(context.Background())
+}
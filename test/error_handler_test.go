@@ -0,0 +1,83 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"omnilogger"
+	"omnilogger/config"
+	"omnilogger/model"
+	pkg "omnilogger/pkg"
+	"strings"
+	"testing"
+)
+
+// FailingDriver fails to format or write every entry.
+type FailingDriver struct {
+	formatErr error
+	writeErr  error
+	writes    int
+}
+
+func (d *FailingDriver) WriteLog(message string) error {
+	d.writes++
+	return d.writeErr
+}
+
+func (d *FailingDriver) FormatLog(messageData model.MessageData) (string, error) {
+	return messageData.Message, d.formatErr
+}
+
+func TestFormatErrorSkipsWrite(t *testing.T) {
+	driver := &FailingDriver{formatErr: errors.New("bad format")}
+	metrics := &omnilogger.MetricsErrorHandler{}
+
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.DEBUG}, nil, driver)
+	logger.SetErrorHandler(metrics)
+	logger.Info("never written")
+
+	if driver.writes != 0 {
+		t.Errorf("expected a failed format to skip the write, got %d writes", driver.writes)
+	}
+	if metrics.FormatErrors() != 1 || metrics.WriteErrors() != 0 {
+		t.Errorf("expected 1 format error and 0 write errors, got %d and %d", metrics.FormatErrors(), metrics.WriteErrors())
+	}
+}
+
+func TestFallbackErrorHandler(t *testing.T) {
+	writeErr := errors.New("disk full")
+	driver := &FailingDriver{writeErr: writeErr}
+	fallback := &MockDriver{}
+
+	var reported error
+	metrics := &omnilogger.MetricsErrorHandler{
+		Next: omnilogger.ErrorHandlerFunc(func(_ pkg.LoggerDriver, messageData model.MessageData, err error) {
+			reported = err
+			omnilogger.FallbackErrorHandler(fallback).HandleError(driver, messageData, err)
+		}),
+	}
+
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.DEBUG}, nil, driver)
+	logger.SetErrorHandler(metrics)
+	logger.With("key", "value").Error("rescued")
+
+	if !errors.Is(reported, writeErr) {
+		t.Errorf("expected the handler to receive the write error, got %v", reported)
+	}
+	if metrics.WriteErrors() != 1 {
+		t.Errorf("expected 1 write error, got %d", metrics.WriteErrors())
+	}
+	if len(fallback.messages) != 1 || fallback.messages[0] != "rescued" {
+		t.Errorf("expected the fallback driver to write the entry, got %v", fallback.messages)
+	}
+}
+
+func TestWriterErrorHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.DEBUG}, nil, &FailingDriver{writeErr: errors.New("broken pipe")})
+	logger.SetErrorHandler(omnilogger.NewWriterErrorHandler(&buf))
+	logger.Warn("lost")
+
+	if !strings.Contains(buf.String(), "write log: broken pipe") || !strings.Contains(buf.String(), "[WARN] lost") {
+		t.Errorf("expected the error report to describe the failure, got %q", buf.String())
+	}
+}
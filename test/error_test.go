@@ -0,0 +1,124 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"omnilogger"
+	"omnilogger/config"
+	"omnilogger/model"
+	drivers "omnilogger/pkg/drivers"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// stackError carries the stack of the place it was created, like errors from stack-aware error packages.
+type stackError struct {
+	message string
+	pcs     []uintptr
+}
+
+func newStackError(message string) *stackError {
+	pcs := make([]uintptr, 8)
+	return &stackError{message: message, pcs: pcs[:runtime.Callers(2, pcs)]}
+}
+
+func (e *stackError) Error() string      { return e.message }
+func (e *stackError) Callers() []uintptr { return e.pcs }
+
+func TestErrAttachesError(t *testing.T) {
+	driver := &RecordingDriver{}
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.DEBUG}, nil, driver)
+
+	err := errors.New("boom")
+	logger.Err(err).With("order_id", 7).Error("could not save order")
+	logger.Info("no error")
+
+	entries := driver.Entries()
+	if entries[0].Error != err || entries[0].Context.MetaData["order_id"] != 7 {
+		t.Errorf("expected the error and field on the child entry, got %v %+v", entries[0].Error, entries[0].Context)
+	}
+	if entries[1].Error != nil {
+		t.Errorf("expected the parent logger to stay without error, got %v", entries[1].Error)
+	}
+}
+
+func TestJSONDriverRendersErrorTree(t *testing.T) {
+	pathErr := &fs.PathError{Op: "open", Path: "config.json", Err: fs.ErrNotExist}
+	err := fmt.Errorf("load settings: %w", errors.Join(pathErr, newStackError("cache miss")))
+
+	formatted, formatErr := (&drivers.JsonCliDriver{}).FormatLog(model.MessageData{
+		Level:   string(omnilogger.ERROR),
+		Message: "startup failed",
+		Error:   err,
+	})
+	if formatErr != nil {
+		t.Fatalf("FormatLog failed: %v", formatErr)
+	}
+
+	var logEntry struct {
+		Error model.ErrorInfo `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(formatted), &logEntry); err != nil {
+		t.Fatalf("failed to unmarshal log entry: %v", err)
+	}
+
+	root := logEntry.Error
+	if root.Type != "*fmt.wrapError" || root.Cause == nil {
+		t.Fatalf("expected a wrapped error with a cause, got %+v", root)
+	}
+	joined := root.Cause
+	if len(joined.Errors) != 2 {
+		t.Fatalf("expected two joined branches, got %+v", joined)
+	}
+	if joined.Errors[0].Type != "*fs.PathError" || joined.Errors[0].Cause == nil || joined.Errors[0].Cause.Message != "file does not exist" {
+		t.Errorf("expected the path error and its cause, got %+v", joined.Errors[0])
+	}
+	stack := joined.Errors[1].StackTrace
+	if len(stack) == 0 || !strings.HasSuffix(stack[0].Function, "TestJSONDriverRendersErrorTree") {
+		t.Errorf("expected the stack carried by the error, got %+v", stack)
+	}
+}
+
+func TestCLIDriverRendersErrorChain(t *testing.T) {
+	err := fmt.Errorf("save order: %w", fs.ErrPermission)
+
+	text, formatErr := (&drivers.CLIDriver{}).FormatLog(model.MessageData{
+		Level:   string(omnilogger.ERROR),
+		Message: "failed",
+		Error:   err,
+	})
+	if formatErr != nil {
+		t.Fatalf("FormatLog failed: %v", formatErr)
+	}
+
+	expected := "\nerror: save order: permission denied (*fmt.wrapError)\n\tcaused by: permission denied (*errors.errorString)"
+	if !strings.HasSuffix(text, expected) {
+		t.Errorf("expected the error chain below the entry, got %q", text)
+	}
+}
+
+func TestLogfmtFormatterRendersErrorTree(t *testing.T) {
+	pathErr := &fs.PathError{Op: "open", Path: "config.json", Err: fs.ErrNotExist}
+	err := fmt.Errorf("load: %w", errors.Join(pathErr, newStackError("cache miss")))
+
+	text, formatErr := (&drivers.LogfmtFormatter{}).Format(model.MessageData{
+		Level:   string(omnilogger.ERROR),
+		Message: "failed",
+		Error:   err,
+	})
+	if formatErr != nil {
+		t.Fatalf("Format failed: %v", formatErr)
+	}
+
+	expected := `error="load: open config.json: file does not exist\ncache miss" error_type=*fmt.wrapError ` +
+		`error.cause="open config.json: file does not exist\ncache miss" error.cause_type=*errors.joinError ` +
+		`error.cause.0="open config.json: file does not exist" error.cause.0_type=*fs.PathError ` +
+		`error.cause.0.cause="file does not exist" error.cause.0.cause_type=*errors.errorString ` +
+		`error.cause.1="cache miss" error.cause.1_type=*test.stackError error.cause.1_stack=`
+	if !strings.Contains(text, expected) || !strings.Contains(text, "TestLogfmtFormatterRendersErrorTree") {
+		t.Errorf("expected the flattened error tree, got %s", text)
+	}
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"omnilogger"
+	"omnilogger/config"
+	"testing"
+)
+
+func TestFatalCallsExitFunc(t *testing.T) {
+	mockDriver := &MockDriver{}
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.DEBUG}, nil, mockDriver)
+
+	var codes []int
+	logger.SetExitFunc(func(code int) { codes = append(codes, code) })
+
+	logger.Fatalf("shutting down: %s", "disk full")
+	logger.With("key", "value").Fatal("from child")
+
+	if len(codes) != 2 || codes[0] != 1 || codes[1] != 1 {
+		t.Errorf("expected two exits with code 1, got %v", codes)
+	}
+	if len(mockDriver.messages) != 2 || mockDriver.messages[0] != "shutting down: disk full" {
+		t.Errorf("expected both fatal messages to be written, got %v", mockDriver.messages)
+	}
+}
+
+func TestTerminalLevelsAndExitCode(t *testing.T) {
+	mockDriver := &MockDriver{}
+	exitCode := 3
+	cfg := config.Config{
+		MinLevel:       omnilogger.DEBUG,
+		TerminalLevels: map[config.LogLevel]bool{omnilogger.ERROR: true},
+		ExitCode:       &exitCode,
+	}
+	logger := omnilogger.NewOmniLogger(cfg, nil, mockDriver)
+
+	var codes []int
+	logger.SetExitFunc(func(code int) { codes = append(codes, code) })
+
+	logger.Logf(omnilogger.INFO, "not terminal %d", 1)
+	logger.Log(omnilogger.WARN, "not terminal 2")
+	if len(codes) != 0 {
+		t.Fatalf("expected no exit for non-terminal levels, got %v", codes)
+	}
+
+	logger.Logf(omnilogger.ERROR, "terminal")
+	logger.Fatal("FATAL stays terminal")
+	if len(codes) != 2 || codes[0] != 3 || codes[1] != 3 {
+		t.Errorf("expected two exits with code 3, got %v", codes)
+	}
+}
+
+func TestFatalCanBeMadeNonTerminal(t *testing.T) {
+	exitCode := 0
+	cfg := config.Config{
+		MinLevel:       omnilogger.DEBUG,
+		TerminalLevels: map[config.LogLevel]bool{omnilogger.FATAL: false, omnilogger.ERROR: true},
+		ExitCode:       &exitCode,
+	}
+	logger := omnilogger.NewOmniLogger(cfg, nil, &MockDriver{})
+
+	var codes []int
+	logger.SetExitFunc(func(code int) { codes = append(codes, code) })
+
+	logger.Fatal("explicitly not terminal")
+	logger.Error("terminal with exit code 0")
+	if len(codes) != 1 || codes[0] != 0 {
+		t.Errorf("expected a single exit with code 0, got %v", codes)
+	}
+}
+
+func TestExitFuncCanPanic(t *testing.T) {
+	logger := omnilogger.NewOmniLogger(config.Config{}, nil, &MockDriver{})
+	logger.SetExitFunc(func(code int) { panic(code) })
+
+	defer func() {
+		if recovered := recover(); recovered != 1 {
+			t.Errorf("expected a panic with code 1, got %v", recovered)
+		}
+	}()
+	logger.Fatal("disabled level still terminates")
+}
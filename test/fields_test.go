@@ -0,0 +1,40 @@
+package test
+
+import (
+	"omnilogger"
+	"omnilogger/config"
+	"omnilogger/model"
+	"testing"
+)
+
+func TestWithFieldsMergesParentContext(t *testing.T) {
+	driver := &RecordingDriver{}
+	parentContext := &model.Context{
+		TransactionID: "tx123",
+		MetaData:      map[string]interface{}{"service": "orders"},
+	}
+	parent := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.DEBUG}, parentContext, driver)
+
+	child := parent.With("order_id", 42).WithFields(map[string]interface{}{"service": "billing"})
+	grandchild := child.WithContext(model.Context{UserID: "user456"})
+
+	grandchild.Info("child message")
+	parent.Info("parent message")
+
+	entries := driver.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	childContext := entries[0].Context
+	if childContext.TransactionID != "tx123" || childContext.UserID != "user456" {
+		t.Errorf("expected inherited transaction and new user, got %+v", childContext)
+	}
+	if childContext.MetaData["order_id"] != 42 || childContext.MetaData["service"] != "billing" {
+		t.Errorf("expected merged metadata, got %v", childContext.MetaData)
+	}
+
+	if entries[1].Context != parentContext || len(parentContext.MetaData) != 1 || parentContext.MetaData["service"] != "orders" {
+		t.Errorf("expected the parent context to be unchanged, got %+v", entries[1].Context)
+	}
+}
@@ -0,0 +1,134 @@
+package test
+
+import (
+	"context"
+	"omnilogger"
+	"omnilogger/config"
+	drivers "omnilogger/pkg/drivers"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fileLines(t *testing.T, path string) []string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read %s: %v", path, err)
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+func TestBufferedFileDriverSyncsErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	driver, err := drivers.NewFileDriverWithOptions(path, drivers.FileOptions{BufferSize: 64 << 10, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewFileDriverWithOptions failed: %v", err)
+	}
+	defer driver.Close()
+	driver.Formatter = &drivers.LogfmtFormatter{}
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.INFO}, nil, driver)
+
+	logger.Info("buffered")
+	if lines := fileLines(t, path); len(lines) != 0 {
+		t.Fatalf("expected INFO to stay in the buffer, found %v", lines)
+	}
+
+	logger.Error("synced")
+	lines := fileLines(t, path)
+	if len(lines) != 2 || !strings.Contains(lines[0], "msg=buffered") || !strings.Contains(lines[1], "msg=synced") {
+		t.Fatalf("expected ERROR to write out both entries in order, found %v", lines)
+	}
+}
+
+func TestBufferedFileDriverWithoutDurability(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	driver, err := drivers.NewFileDriverWithOptions(path, drivers.FileOptions{
+		BufferSize:    64 << 10,
+		FlushInterval: time.Hour,
+		Durability:    config.DurabilityNone,
+	})
+	if err != nil {
+		t.Fatalf("NewFileDriverWithOptions failed: %v", err)
+	}
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.INFO}, nil, driver)
+
+	logger.Error("stays buffered")
+	if lines := fileLines(t, path); len(lines) != 0 {
+		t.Fatalf("expected ERROR to stay in the buffer, found %v", lines)
+	}
+
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if lines := fileLines(t, path); len(lines) != 1 {
+		t.Fatalf("expected Flush to write the entry, found %v", lines)
+	}
+
+	logger.Info("written on close")
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if lines := fileLines(t, path); len(lines) != 2 {
+		t.Fatalf("expected Shutdown to write the entry, found %v", lines)
+	}
+}
+
+func TestBufferedFileDriverFlushInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	driver, err := drivers.NewFileDriverWithOptions(path, drivers.FileOptions{BufferSize: 64 << 10, FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewFileDriverWithOptions failed: %v", err)
+	}
+	defer driver.Close()
+
+	driver.WriteLog("eventually written")
+	deadline := time.Now().Add(2 * time.Second)
+	for len(fileLines(t, path)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the flush interval to write the entry")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFileDriverFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	logPath := filepath.Join(dir, "audit.log")
+	content := `{"files": {"audit": {"path": "` + logPath + `", "buffer_size": 4096, "flush_interval": "1h", "durability": "flush", "sync_level": "warn"}}}`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("could not write config: %v", err)
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Files["audit"].FlushInterval != config.Duration(time.Hour) {
+		t.Errorf("expected a flush interval of 1h, got %v", time.Duration(cfg.Files["audit"].FlushInterval))
+	}
+	driver, err := drivers.NewFileDriverFromConfig(cfg.Files["audit"])
+	if err != nil {
+		t.Fatalf("NewFileDriverFromConfig failed: %v", err)
+	}
+	defer driver.Close()
+
+	driver.WriteLevel("INFO", "buffered")
+	if lines := fileLines(t, logPath); len(lines) != 0 {
+		t.Fatalf("expected INFO to stay in the buffer, found %v", lines)
+	}
+	driver.WriteLevel("WARN", "flushed")
+	if lines := fileLines(t, logPath); len(lines) != 2 {
+		t.Fatalf("expected WARN to flush the buffer, found %v", lines)
+	}
+
+	if _, err := drivers.NewFileDriverFromConfig(config.FileConfig{Path: logPath, Durability: "always"}); err == nil {
+		t.Error("expected an error for an unknown durability")
+	}
+}
@@ -0,0 +1,313 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"omnilogger"
+	"omnilogger/config"
+	"omnilogger/model"
+	pkg "omnilogger/pkg"
+	drivers "omnilogger/pkg/drivers"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFileDriverWithTextFormatter(t *testing.T) {
+	file, err := os.CreateTemp("", "logfile*.log")
+	if err != nil {
+		t.Fatalf("could not create temp file: %v", err)
+	}
+	defer os.Remove(file.Name())
+
+	driver, err := drivers.NewFileDriver(file.Name())
+	if err != nil {
+		t.Fatalf("could not create FileDriver: %v", err)
+	}
+	defer driver.Close()
+	driver.Formatter = &drivers.TextFormatter{}
+
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.INFO}, nil, driver)
+	logger.Info("plain text in a file")
+
+	content, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatalf("could not read temp file: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "[INFO] timestamp: ") || !strings.HasSuffix(string(content), "msg : plain text in a file \n") {
+		t.Errorf("expected the text layout, got %q", content)
+	}
+}
+
+func TestWriterDriverWithJSONFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	driver := drivers.NewWriterDriver(&buf, &drivers.JSONFormatter{})
+
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.INFO}, &model.Context{UserID: "user456"}, driver)
+	logger.Warn("json on any writer")
+
+	var logEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &logEntry); err != nil {
+		t.Fatalf("failed to unmarshal log entry %q: %v", buf.String(), err)
+	}
+	if logEntry["message"] != "json on any writer" || logEntry["user_id"] != "user456" {
+		t.Errorf("expected the JSON layout, got %v", logEntry)
+	}
+}
+
+func TestCLIDriverWithFormatterFunc(t *testing.T) {
+	driver := drivers.NewCLIDriver(pkg.FormatterFunc(func(messageData model.MessageData) (string, error) {
+		return messageData.Level + " " + messageData.Message, nil
+	}))
+
+	formatted, err := driver.FormatLog(model.MessageData{Level: "INFO", Message: "custom"})
+	if err != nil || formatted != "INFO custom" {
+		t.Errorf("expected the custom layout, got %q (%v)", formatted, err)
+	}
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	messageData := model.MessageData{
+		Level:     "INFO",
+		Message:   `order "42" saved`,
+		Caller:    model.Frame{File: "main.go", Line: 42, Function: "main.save"},
+		Timestamp: "2024-05-01T10:00:00Z",
+		Context: &model.Context{
+			UserID: "user456",
+			MetaData: map[string]interface{}{
+				"zone":    "eu-west",
+				"note":    "line one\nline two",
+				"empty":   "",
+				"count":   3,
+				"request": map[string]interface{}{"path": "/orders", "method": "POST"},
+				"bad key": true,
+			},
+		},
+	}
+
+	formatted, err := (&drivers.LogfmtFormatter{}).Format(messageData)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+
+	expected := `level=info ts=2024-05-01T10:00:00Z msg="order \"42\" saved" caller="main.go:42 main.save" user_id=user456 ` +
+		`bad_key=true count=3 empty="" note="line one\nline two" request.method=POST request.path=/orders zone=eu-west`
+	if formatted != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, formatted)
+	}
+}
+
+func TestConsoleFormatter(t *testing.T) {
+	messageData := model.MessageData{
+		Level:     "WARN",
+		Message:   "disk almost full",
+		Caller:    model.Frame{File: "/srv/app/storage/disk.go", Line: 42},
+		Timestamp: "2024-05-01T10:04:05Z",
+		Context:   &model.Context{UserID: "user456", MetaData: map[string]interface{}{"free": "5%"}},
+	}
+
+	plain, err := drivers.NewConsoleFormatter(drivers.ColorNever).Format(messageData)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	expected := "10:04:05.000 WARN  storage/disk.go:42       disk almost full                         user_id=user456 free=5%"
+	if plain != expected {
+		t.Errorf("expected\n%q\ngot\n%q", expected, plain)
+	}
+
+	colored, err := drivers.NewConsoleFormatter(drivers.ColorAlways).Format(messageData)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if !strings.Contains(colored, "\x1b[33mWARN \x1b[0m") || !strings.Contains(colored, "\x1b[2muser_id=\x1b[0muser456") {
+		t.Errorf("expected a yellow level and dimmed keys, got %q", colored)
+	}
+}
+
+func TestConsoleFormatterAlignsNonASCII(t *testing.T) {
+	formatter := drivers.NewConsoleFormatter(drivers.ColorNever)
+	ascii, _ := formatter.Format(model.MessageData{Level: "INFO", Message: "saved", Caller: model.Frame{File: "/srv/app/orders.go", Line: 1}, Context: &model.Context{UserID: "u"}})
+	accented, _ := formatter.Format(model.MessageData{Level: "INFO", Message: "sauvé", Caller: model.Frame{File: "/srv/app/commandé.go", Line: 1}, Context: &model.Context{UserID: "u"}})
+
+	if column := strings.Index(ascii, "user_id="); utf8.RuneCountInString(accented[:strings.Index(accented, "user_id=")]) != column {
+		t.Errorf("expected the fields in the same column, got\n%s\n%s", ascii, accented)
+	}
+}
+
+func TestColorEnabledHonorsEnvironment(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "1")
+	if !drivers.ColorEnabled(nil) {
+		t.Error("expected FORCE_COLOR to enable colors")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if drivers.ColorEnabled(nil) {
+		t.Error("expected NO_COLOR to win over FORCE_COLOR")
+	}
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	file, err := os.CreateTemp("", "notatty*.log")
+	if err != nil {
+		t.Fatalf("could not create temp file: %v", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	if drivers.ColorEnabled(file) {
+		t.Error("expected no colors for a regular file")
+	}
+}
+
+func TestConsoleFormatterColorsFollowDriverOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs /dev/null as a character device")
+	}
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	t.Setenv("TERM", "xterm")
+
+	terminal, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("could not open %s: %v", os.DevNull, err)
+	}
+	defer terminal.Close()
+	formatter := drivers.NewConsoleFormatter(drivers.ColorAuto)
+	entry := model.MessageData{Level: "WARN", Message: "colored?"}
+
+	colored, _ := drivers.NewWriterDriver(terminal, formatter).FormatLog(entry)
+	if !strings.Contains(colored, "\x1b[") {
+		t.Errorf("expected colors for a character device, got %q", colored)
+	}
+
+	fileDriver, err := drivers.NewFileDriver(filepath.Join(t.TempDir(), "app.log"))
+	if err != nil {
+		t.Fatalf("NewFileDriver failed: %v", err)
+	}
+	defer fileDriver.Close()
+	fileDriver.Formatter = formatter
+	if plain, _ := fileDriver.FormatLog(entry); strings.Contains(plain, "\x1b[") {
+		t.Errorf("expected no colors in a log file, got %q", plain)
+	}
+}
+
+func TestPatternFormatter(t *testing.T) {
+	formatter, err := drivers.NewPatternFormatter("%time{2006-01-02} [%-5level] %.12caller{short} %txid %msg%% %meta{order_id} |%meta|")
+	if err != nil {
+		t.Fatalf("NewPatternFormatter failed: %v", err)
+	}
+
+	formatted, err := formatter.Format(model.MessageData{
+		Level:     "INFO",
+		Message:   "order saved",
+		Caller:    model.Frame{File: "/srv/app/orders/service.go", Line: 42},
+		Timestamp: "2024-05-01T10:04:05Z",
+		Context: &model.Context{
+			TransactionID: "tx123",
+			MetaData:      map[string]interface{}{"order_id": 7, "amount": 9.5},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+
+	expected := "2024-05-01 [INFO ] orders/servi tx123 order saved% 7 |amount=9.5 order_id=7|"
+	if formatted != expected {
+		t.Errorf("expected %q, got %q", expected, formatted)
+	}
+
+	if _, err := drivers.NewPatternFormatter("%msg %unknown"); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestFormatterFromConfigFile(t *testing.T) {
+	file, err := os.CreateTemp("", "config*.json")
+	if err != nil {
+		t.Fatalf("could not create temp file: %v", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(`{"format": {"pattern": "%5level: %msg"}}`)
+	if err != nil {
+		t.Fatalf("could not write to temp file: %v", err)
+	}
+	file.Close()
+
+	cfg, err := config.LoadConfig(file.Name())
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	formatter, err := drivers.NewFormatter(cfg.Format)
+	if err != nil {
+		t.Fatalf("NewFormatter failed: %v", err)
+	}
+
+	formatted, _ := formatter.Format(model.MessageData{Level: "WARN", Message: "from config"})
+	if formatted != " WARN: from config" {
+		t.Errorf("expected the configured pattern, got %q", formatted)
+	}
+
+	if _, err := drivers.NewFormatter(config.FormatConfig{Type: "xml"}); err == nil {
+		t.Error("expected an error for an unknown formatter type")
+	}
+}
+
+func TestJSONFormatterReservedKeys(t *testing.T) {
+	entry := model.MessageData{
+		Level:     "INFO",
+		Message:   "order saved",
+		Timestamp: "2024-05-01T10:00:00Z",
+		Context:   &model.Context{MetaData: map[string]interface{}{"level": "debug", "order_id": 7}},
+	}
+
+	prefixed, err := (&drivers.JSONFormatter{}).Format(entry)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	expected := `{"timestamp":"2024-05-01T10:00:00Z","level":"INFO","message":"order saved","stack_trace":"unknown","fields.level":"debug","order_id":7}`
+	if prefixed != expected {
+		t.Errorf("expected %s, got %s", expected, prefixed)
+	}
+
+	literal := entry
+	literal.Context = &model.Context{MetaData: map[string]interface{}{"level": "debug", "fields.level": "literal"}}
+	repeated, _ := (&drivers.JSONFormatter{}).Format(literal)
+	expected = `{"timestamp":"2024-05-01T10:00:00Z","level":"INFO","message":"order saved","stack_trace":"unknown","fields.level":"literal","fields.fields.level":"debug"}`
+	if repeated != expected {
+		t.Errorf("expected %s, got %s", expected, repeated)
+	}
+
+	nested, err := (&drivers.JSONFormatter{KeyCollision: config.KeyCollisionNest}).Format(entry)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	expected = `{"timestamp":"2024-05-01T10:00:00Z","level":"INFO","message":"order saved","stack_trace":"unknown","fields":{"level":"debug","order_id":7}}`
+	if nested != expected {
+		t.Errorf("expected %s, got %s", expected, nested)
+	}
+}
+
+func TestJSONFormatterRejectsReservedKeys(t *testing.T) {
+	var buf bytes.Buffer
+	driver := drivers.NewWriterDriver(&buf, &drivers.JSONFormatter{KeyCollision: config.KeyCollisionError})
+
+	var reported error
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.INFO}, nil, driver)
+	logger.SetErrorHandler(omnilogger.ErrorHandlerFunc(func(_ pkg.LoggerDriver, _ model.MessageData, err error) {
+		reported = err
+	}))
+
+	logger.With("timestamp", "yesterday").Info("order saved")
+
+	if !errors.Is(reported, drivers.ErrReservedKey) {
+		t.Errorf("expected ErrReservedKey to be reported, got %v", reported)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing to be written, got %q", buf.String())
+	}
+}
@@ -0,0 +1,160 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"omnilogger/model"
+	drivers "omnilogger/pkg/drivers"
+	"strings"
+	"testing"
+	"time"
+)
+
+type jsonPoint struct {
+	X, Y int
+}
+
+// TestJSONFormatterMatchesEncodingJSON checks every metadata value against json.Marshal.
+func TestJSONFormatterMatchesEncodingJSON(t *testing.T) {
+	values := []interface{}{
+		nil,
+		"plain",
+		"<html> & \"quotes\" \\ \n\r\t\b\f \x01 \u2028\u2029 é 日本",
+		"invalid \xff utf-8",
+		true,
+		-42, int8(-8), int16(16), int32(-32), int64(math.MinInt64),
+		uint(7), uint8(8), uint16(16), uint32(32), uint64(math.MaxUint64),
+		0.0, 0.1, -1.5, 1e-7, 123456789.125, 1e21, 1e20, 5e-324, math.MaxFloat64,
+		float32(0.1), float32(1e-7), float32(3.4e38),
+		1500 * time.Millisecond,
+		time.Date(2024, 5, 1, 10, 4, 5, 123000000, time.UTC),
+		time.Date(2024, 5, 1, 10, 4, 5, 0, time.FixedZone("CEST", 2*60*60)),
+		map[string]interface{}{"b": 1, "a": map[string]interface{}{"d": "x", "c": []int{1, 2}}},
+		[]string{"a", "b"},
+		jsonPoint{X: 1, Y: 2},
+		&jsonPoint{X: 3},
+		net.ParseIP("10.0.0.1"),
+		json.RawMessage(`{"raw": true}`),
+		[]byte("bytes"),
+	}
+
+	formatter := &drivers.JSONFormatter{}
+	for _, value := range values {
+		formatted, err := formatter.Format(model.MessageData{
+			Level:   "INFO",
+			Message: "m",
+			Context: &model.Context{MetaData: map[string]interface{}{"v": value}},
+		})
+		if err != nil {
+			t.Errorf("Format(%#v) failed: %v", value, err)
+			continue
+		}
+
+		encoded, _ := json.Marshal(value)
+		expected := `{"timestamp":"","level":"INFO","message":"m","stack_trace":"unknown","v":` + string(encoded) + `}`
+		if formatted != expected {
+			t.Errorf("value %#v:\nexpected %s\ngot      %s", value, expected, formatted)
+		}
+	}
+}
+
+func TestJSONFormatterEncodesErrorsAndFrames(t *testing.T) {
+	formatted, err := (&drivers.JSONFormatter{}).Format(model.MessageData{
+		Level:      "ERROR",
+		Message:    "failed <again>",
+		Caller:     model.Frame{Function: "main.run", File: "/srv/main.go", Line: 12},
+		StackTrace: []model.Frame{{Function: "main.run", File: "/srv/main.go", Line: 12}},
+		Goroutines: "goroutine 1 [running]:",
+		Error:      errors.Join(errors.New("first"), errors.New("second")),
+		Context:    &model.Context{MetaData: map[string]interface{}{"cause": errors.New("disk full")}},
+	})
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+
+	stack, _ := json.Marshal([]model.Frame{{Function: "main.run", File: "/srv/main.go", Line: 12}})
+	errorInfo, _ := json.Marshal(model.DescribeError(errors.Join(errors.New("first"), errors.New("second"))))
+	expected := `{"timestamp":"","level":"ERROR","message":"failed \u003cagain\u003e","stack_trace":"/srv/main.go:12 main.run",` +
+		`"cause":"disk full","error":` + string(errorInfo) + `,"stack":` + string(stack) + `,"goroutines":"goroutine 1 [running]:"}`
+	if formatted != expected {
+		t.Errorf("expected %s\ngot      %s", expected, formatted)
+	}
+}
+
+func TestJSONFormatterReportsUnsupportedValues(t *testing.T) {
+	_, err := (&drivers.JSONFormatter{}).Format(model.MessageData{
+		Context: &model.Context{MetaData: map[string]interface{}{"ratio": math.NaN()}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "unsupported value") {
+		t.Errorf("expected an unsupported value error, got %v", err)
+	}
+}
+
+func benchmarkEntry() model.MessageData {
+	return model.MessageData{
+		Level:     "INFO",
+		Message:   "order saved",
+		Caller:    model.Frame{Function: "main.saveOrder", File: "/srv/app/orders/service.go", Line: 42},
+		Timestamp: "2024-05-01T10:04:05Z",
+		Context: &model.Context{
+			TransactionID: "tx123",
+			UserID:        "user456",
+			MetaData: map[string]interface{}{
+				"order_id": 7,
+				"amount":   9.5,
+				"paid":     true,
+				"region":   "eu-west-1",
+				"elapsed":  1500 * time.Millisecond,
+				"at":       time.Date(2024, 5, 1, 10, 4, 5, 0, time.UTC),
+			},
+		},
+	}
+}
+
+func BenchmarkJSONFormatter(b *testing.B) {
+	formatter := &drivers.JSONFormatter{}
+	messageData := benchmarkEntry()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := formatter.Format(messageData); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkJSONFormatterAppendFormat(b *testing.B) {
+	formatter := &drivers.JSONFormatter{}
+	messageData := benchmarkEntry()
+	buf := make([]byte, 0, 1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = formatter.AppendFormat(buf[:0], messageData); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkJSONMarshalMap is the previous approach: a map per entry passed to json.Marshal.
+func BenchmarkJSONMarshalMap(b *testing.B) {
+	messageData := benchmarkEntry()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logEntry := map[string]interface{}{
+			"level":          messageData.Level,
+			"timestamp":      messageData.Timestamp,
+			"transaction_id": messageData.Context.TransactionID,
+			"user_id":        messageData.Context.UserID,
+			"stack_trace":    messageData.Caller.String(),
+			"message":        messageData.Message,
+		}
+		for key, value := range messageData.Context.MetaData {
+			logEntry[key] = value
+		}
+		if _, err := json.Marshal(logEntry); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
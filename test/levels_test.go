@@ -0,0 +1,121 @@
+package test
+
+import (
+	"omnilogger"
+	"omnilogger/config"
+	"omnilogger/model"
+	"os"
+	"testing"
+)
+
+func TestMinLevelThreshold(t *testing.T) {
+	mockDriver := &MockDriver{}
+
+	// WARN and above are enabled, INFO is forced on and ERROR forced off by the overrides
+	cfg := config.Config{
+		MinLevel: omnilogger.WARN,
+		LogLevels: map[config.LogLevel]bool{
+			omnilogger.INFO:  true,
+			omnilogger.ERROR: false,
+		},
+	}
+	logger := omnilogger.NewOmniLogger(cfg, nil, mockDriver)
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	if len(mockDriver.messages) != 2 || mockDriver.messages[0] != "info" || mockDriver.messages[1] != "warn" {
+		t.Errorf("expected [info warn], got %v", mockDriver.messages)
+	}
+}
+
+func TestCustomLevelSeverity(t *testing.T) {
+	const trace config.LogLevel = "TRACE"
+	config.RegisterLevel(trace, 5)
+
+	cfg := config.Config{MinLevel: trace}
+	if !cfg.Enabled(trace) || !cfg.Enabled(omnilogger.DEBUG) {
+		t.Error("expected TRACE and DEBUG to be enabled with a TRACE threshold")
+	}
+
+	cfg.MinLevel = omnilogger.DEBUG
+	if cfg.Enabled(trace) {
+		t.Error("expected TRACE to be disabled with a DEBUG threshold")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := config.ParseLevel(" warn ")
+	if err != nil || level != omnilogger.WARN {
+		t.Errorf("expected WARN, got %q (%v)", level, err)
+	}
+
+	if _, err := config.ParseLevel("verbose"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
+
+func TestLoadConfigMinLevel(t *testing.T) {
+	file, err := os.CreateTemp("", "config*.json")
+	if err != nil {
+		t.Fatalf("could not create temp file: %v", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(`{"min_level": "warn", "log_levels": {"debug": true}}`)
+	if err != nil {
+		t.Fatalf("could not write to temp file: %v", err)
+	}
+	file.Close()
+
+	cfg, err := config.LoadConfig(file.Name())
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.MinLevel != omnilogger.WARN {
+		t.Errorf("expected min level WARN, got %q", cfg.MinLevel)
+	}
+	if !cfg.Enabled(omnilogger.DEBUG) || cfg.Enabled(omnilogger.INFO) || !cfg.Enabled(omnilogger.ERROR) {
+		t.Error("expected DEBUG, WARN and above to be enabled")
+	}
+}
+
+// CountingDriver records how many entries it was asked to format.
+type CountingDriver struct {
+	MockDriver
+	formatted int
+}
+
+func (d *CountingDriver) FormatLog(messageData model.MessageData) (string, error) {
+	d.formatted++
+	return d.MockDriver.FormatLog(messageData)
+}
+
+func TestPerDriverLevels(t *testing.T) {
+	cliDriver := &MockDriver{}
+	fileDriver := &CountingDriver{}
+
+	cfg := config.Config{MinLevel: omnilogger.DEBUG}
+	logger := omnilogger.NewOmniLogger(cfg, nil,
+		cliDriver,
+		omnilogger.WithDriverOptions(fileDriver, omnilogger.DriverOptions{MinLevel: omnilogger.WARN}),
+	)
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	if len(cliDriver.messages) != 4 {
+		t.Errorf("expected the CLI driver to receive 4 messages, got %v", cliDriver.messages)
+	}
+	if len(fileDriver.messages) != 2 || fileDriver.messages[0] != "warn" || fileDriver.messages[1] != "error" {
+		t.Errorf("expected the file driver to receive [warn error], got %v", fileDriver.messages)
+	}
+	if fileDriver.formatted != 2 {
+		t.Errorf("expected filtered levels to skip formatting, got %d FormatLog calls", fileDriver.formatted)
+	}
+}
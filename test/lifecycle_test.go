@@ -0,0 +1,91 @@
+package test
+
+import (
+	"context"
+	"omnilogger"
+	"omnilogger/config"
+	"testing"
+	"time"
+)
+
+// LifecycleDriver records writes, flushes and closes.
+type LifecycleDriver struct {
+	*BlockingDriver
+	flushes int
+	closes  int
+}
+
+func (d *LifecycleDriver) Flush() error {
+	d.flushes++
+	return nil
+}
+
+func (d *LifecycleDriver) Close() error {
+	d.closes++
+	return nil
+}
+
+func TestShutdownDrainsAndCloses(t *testing.T) {
+	driver := &LifecycleDriver{BlockingDriver: NewBlockingDriver()}
+	close(driver.release)
+
+	cfg := asyncConfig(config.OverflowBlock)
+	cfg.Async.QueueSize = 100
+	logger := omnilogger.NewOmniLogger(cfg, nil, driver)
+	for i := 0; i < 50; i++ {
+		logger.Info("pending")
+	}
+
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if len(driver.Messages()) != 50 || driver.flushes != 1 {
+		t.Errorf("expected 50 messages and 1 flush after Flush, got %d and %d", len(driver.Messages()), driver.flushes)
+	}
+
+	logger.Info("last")
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if len(driver.Messages()) != 51 || driver.closes != 1 {
+		t.Errorf("expected 51 messages and 1 close after Shutdown, got %d and %d", len(driver.Messages()), driver.closes)
+	}
+
+	// A second shutdown must not close the driver again
+	logger.Shutdown(context.Background())
+	if driver.closes != 1 {
+		t.Errorf("expected the driver to be closed once, got %d", driver.closes)
+	}
+}
+
+func TestShutdownHonorsDeadline(t *testing.T) {
+	driver := NewBlockingDriver() // Never released, so the queue cannot drain.
+	defer close(driver.release)
+
+	logger := omnilogger.NewOmniLogger(asyncConfig(config.OverflowBlock), nil, driver)
+	logger.Info("stuck")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := logger.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestReplacedExitFuncKeepsDriversOpen(t *testing.T) {
+	driver := &LifecycleDriver{BlockingDriver: NewBlockingDriver()}
+	close(driver.release)
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.INFO}, nil, driver)
+
+	var codes []int
+	logger.SetExitFunc(func(code int) { codes = append(codes, code) })
+	logger.Fatal("recorded")
+	logger.Info("still logging")
+
+	if len(codes) != 1 || driver.flushes != 1 || driver.closes != 0 {
+		t.Errorf("expected one exit, one flush and no close, got %v, %d and %d", codes, driver.flushes, driver.closes)
+	}
+	if messages := driver.Messages(); len(messages) != 2 || messages[1] != "still logging" {
+		t.Errorf("expected logging to go on after the exit function returned, got %v", messages)
+	}
+}
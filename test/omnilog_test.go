@@ -7,6 +7,7 @@ import (
 	"omnilogger/model"
 	drivers "omnilogger/pkg/drivers"
 	"os"
+	"sync"
 	"testing"
 	"time"
 )
@@ -24,6 +25,29 @@ func (m *MockDriver) FormatLog(messageData model.MessageData) (string, error) {
 	return messageData.Message, nil
 }
 
+// RecordingDriver keeps every entry it is asked to format.
+type RecordingDriver struct {
+	mu      sync.Mutex
+	entries []model.MessageData
+}
+
+func (r *RecordingDriver) WriteLog(message string) error {
+	return nil
+}
+
+func (r *RecordingDriver) FormatLog(messageData model.MessageData) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, messageData)
+	return messageData.Message, nil
+}
+
+func (r *RecordingDriver) Entries() []model.MessageData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]model.MessageData(nil), r.entries...)
+}
+
 func TestFileDriver(t *testing.T) {
 	// Create a temporary file to write logs
 	file, err := os.CreateTemp("", "logfile*.log")
@@ -83,11 +107,11 @@ func TestFileDriverWithFormatting(t *testing.T) {
 
 	timestamp := time.Now().Format(time.RFC3339)
 	messageData := model.MessageData{
-		Level:      string(omnilogger.INFO),
-		Message:    "This is a formatted log message",
-		StackTrace: "example.go:123 main.someFunction",
-		Context:    context,
-		Timestamp:  timestamp,
+		Level:     string(omnilogger.INFO),
+		Message:   "This is a formatted log message",
+		Caller:    model.Frame{File: "example.go", Line: 123, Function: "main.someFunction"},
+		Context:   context,
+		Timestamp: timestamp,
 	}
 
 	// Use FileDriver's FormatLog function to format the log
@@ -234,10 +258,10 @@ func TestCLIDriver_FormatLog_NoContext(t *testing.T) {
 	// Create log message data without a context
 	timestamp := time.Now().Format(time.RFC3339)
 	messageData := model.MessageData{
-		Level:      string(omnilogger.INFO),
-		Message:    "Test message without context",
-		StackTrace: "file.go:123",
-		Timestamp:  timestamp,
+		Level:     string(omnilogger.INFO),
+		Message:   "Test message without context",
+		Caller:    model.Frame{File: "file.go", Line: 123},
+		Timestamp: timestamp,
 	}
 
 	// Format the log
@@ -267,11 +291,11 @@ func TestCLIDriver_FormatLog_WithContext(t *testing.T) {
 	}
 	timestamp := time.Now().Format(time.RFC3339)
 	messageData := model.MessageData{
-		Level:      string(omnilogger.INFO),
-		Message:    "Test message with context",
-		StackTrace: "file.go:123",
-		Context:    context,
-		Timestamp:  timestamp,
+		Level:     string(omnilogger.INFO),
+		Message:   "Test message with context",
+		Caller:    model.Frame{File: "file.go", Line: 123},
+		Context:   context,
+		Timestamp: timestamp,
 	}
 
 	// Format the log
@@ -294,10 +318,10 @@ func TestCLIDriver_WriteLog(t *testing.T) {
 	// Create log message data without a context
 	timestamp := time.Now().Format(time.RFC3339)
 	messageData := model.MessageData{
-		Level:      string(omnilogger.INFO),
-		Message:    "Test CLI message",
-		StackTrace: "file.go:123",
-		Timestamp:  timestamp,
+		Level:     string(omnilogger.INFO),
+		Message:   "Test CLI message",
+		Caller:    model.Frame{File: "file.go", Line: 123},
+		Timestamp: timestamp,
 	}
 
 	// Format the log
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"omnilogger"
+	"omnilogger/config"
+	"omnilogger/model"
+	pkg "omnilogger/pkg"
+	drivers "omnilogger/pkg/drivers"
+	"reflect"
+	"testing"
+)
+
+func orderedEntry() model.MessageData {
+	return model.MessageData{
+		Level:     "INFO",
+		Message:   "order saved",
+		Caller:    model.Frame{File: "main.go", Line: 7},
+		Timestamp: "2024-05-01T10:00:00Z",
+		Context: &model.Context{
+			UserID:   "user456",
+			MetaData: map[string]interface{}{"zone": "eu", "amount": 9.5, "order_id": 7},
+		},
+	}
+}
+
+func TestFormattersUseStableOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter pkg.Formatter
+		expected  string
+	}{
+		{
+			name:      "json",
+			formatter: &drivers.JSONFormatter{},
+			expected:  `{"timestamp":"2024-05-01T10:00:00Z","level":"INFO","message":"order saved","stack_trace":"main.go:7","user_id":"user456","amount":9.5,"order_id":7,"zone":"eu"}`,
+		},
+		{
+			name:      "text",
+			formatter: &drivers.TextFormatter{},
+			expected:  "[INFO] timestamp: 2024-05-01T10:00:00Z user_id: user456 amount: 9.5 order_id: 7 zone: eu  trace: main.go:7  msg : order saved ",
+		},
+		{
+			name:      "logfmt",
+			formatter: &drivers.LogfmtFormatter{Order: config.FieldOrder{Fields: []string{"message", "level"}}},
+			expected:  "msg=\"order saved\" level=info ts=2024-05-01T10:00:00Z caller=main.go:7 user_id=user456 amount=9.5 order_id=7 zone=eu",
+		},
+		{
+			name:      "json with metadata first",
+			formatter: &drivers.JSONFormatter{Order: config.FieldOrder{Fields: []string{"metadata", "message"}}},
+			expected:  `{"amount":9.5,"order_id":7,"zone":"eu","message":"order saved","timestamp":"2024-05-01T10:00:00Z","level":"INFO","stack_trace":"main.go:7","user_id":"user456"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				formatted, err := test.formatter.Format(orderedEntry())
+				if err != nil {
+					t.Fatalf("Format failed: %v", err)
+				}
+				if formatted != test.expected {
+					t.Fatalf("expected %s, got %s", test.expected, formatted)
+				}
+			}
+		})
+	}
+}
+
+func TestMetadataInsertionOrder(t *testing.T) {
+	driver := &RecordingDriver{}
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.DEBUG}, nil, driver)
+
+	logger.With("zone", "eu").With("order_id", 7).With("amount", 9.5).With("zone", "us").Info("order saved")
+
+	entries := driver.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	keys := entries[0].Context.Keys()
+	if !reflect.DeepEqual(keys, []string{"zone", "order_id", "amount"}) {
+		t.Errorf("expected keys in insertion order, got %v", keys)
+	}
+
+	formatter := &drivers.LogfmtFormatter{Order: config.FieldOrder{Metadata: config.MetadataInsertion}}
+	formatted, _ := formatter.Format(model.MessageData{Level: "INFO", Message: "m", Timestamp: "t", Context: entries[0].Context})
+	expected := "level=info ts=t msg=m caller=unknown zone=us order_id=7 amount=9.5"
+	if formatted != expected {
+		t.Errorf("expected %q, got %q", expected, formatted)
+	}
+}
+
+func TestFieldOrderValidation(t *testing.T) {
+	if _, err := drivers.NewFormatter(config.FormatConfig{Type: "json", Order: config.FieldOrder{Fields: []string{"lvl"}}}); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+	if _, err := drivers.NewFormatter(config.FormatConfig{Type: "json", Order: config.FieldOrder{Metadata: "random"}}); err == nil {
+		t.Error("expected an error for an unknown metadata order")
+	}
+}
@@ -0,0 +1,156 @@
+package test
+
+import (
+	"errors"
+	drivers "omnilogger/pkg/drivers"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFileDriverReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	driver, err := drivers.NewFileDriverWithOptions(path, drivers.FileOptions{BufferSize: 4096, FlushInterval: time.Hour, CheckInterval: -1})
+	if err != nil {
+		t.Fatalf("NewFileDriverWithOptions failed: %v", err)
+	}
+	defer driver.Close()
+
+	driver.WriteLog("before rotation")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("could not move the file: %v", err)
+	}
+	if err := driver.Reopen(); err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	driver.WriteLog("after rotation")
+	driver.Flush()
+
+	if lines := fileLines(t, path+".1"); !reflect.DeepEqual(lines, []string{"before rotation"}) {
+		t.Errorf("expected the buffered entry in the moved file, found %v", lines)
+	}
+	if lines := fileLines(t, path); !reflect.DeepEqual(lines, []string{"after rotation"}) {
+		t.Errorf("expected the new entry in a new file, found %v", lines)
+	}
+}
+
+func TestFileDriverDetectsMovedAndDeletedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	driver, err := drivers.NewFileDriverWithOptions(path, drivers.FileOptions{Durability: "none", CheckInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewFileDriverWithOptions failed: %v", err)
+	}
+	defer driver.Close()
+
+	driver.WriteLog("first")
+	os.Rename(path, path+".1")
+	time.Sleep(5 * time.Millisecond)
+	driver.WriteLog("second")
+	if lines := fileLines(t, path); !reflect.DeepEqual(lines, []string{"second"}) {
+		t.Fatalf("expected a moved file to be recreated, found %v", lines)
+	}
+
+	os.Remove(path)
+	time.Sleep(5 * time.Millisecond)
+	driver.WriteLog("third")
+	if lines := fileLines(t, path); !reflect.DeepEqual(lines, []string{"third"}) {
+		t.Fatalf("expected a deleted file to be recreated, found %v", lines)
+	}
+}
+
+func TestFileDriverCreatesParentDirectories(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs", "app")
+	path := filepath.Join(dir, "app.log")
+	driver, err := drivers.NewFileDriverWithOptions(path, drivers.FileOptions{DirMode: 0700, FileMode: 0600})
+	if err != nil {
+		t.Fatalf("NewFileDriverWithOptions failed: %v", err)
+	}
+	defer driver.Close()
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	dirInfo, _ := os.Stat(dir)
+	fileInfo, _ := os.Stat(path)
+	if dirInfo.Mode().Perm() != 0700 || fileInfo.Mode().Perm() != 0600 {
+		t.Errorf("expected modes 0700 and 0600, got %v and %v", dirInfo.Mode().Perm(), fileInfo.Mode().Perm())
+	}
+}
+
+func TestFileDriverReopensOnSIGHUP(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGHUP is not delivered on Windows")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	driver, err := drivers.NewFileDriverWithOptions(path, drivers.FileOptions{
+		Durability:     "none",
+		CheckInterval:  -1,
+		ReopenOnSIGHUP: true,
+		OnError:        func(err error) { t.Errorf("reopen failed: %v", err) },
+	})
+	if err != nil {
+		t.Fatalf("NewFileDriverWithOptions failed: %v", err)
+	}
+	defer driver.Close()
+
+	os.Rename(path, path+".1")
+	process, _ := os.FindProcess(os.Getpid())
+	if err := process.Signal(syscall.SIGHUP); err != nil {
+		t.Fatalf("could not send SIGHUP: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected SIGHUP to recreate the file")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	driver.WriteLog("after SIGHUP")
+	if lines := fileLines(t, path); !reflect.DeepEqual(lines, []string{"after SIGHUP"}) {
+		t.Errorf("expected the entry in the reopened file, found %v", lines)
+	}
+}
+
+func TestFileDriverKeepsWritingWhenReopenFails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("directories holding open files cannot be moved on Windows")
+	}
+	dir := t.TempDir()
+	logDir := filepath.Join(dir, "logs")
+	driver, err := drivers.NewFileDriverWithOptions(filepath.Join(logDir, "app.log"), drivers.FileOptions{Durability: "none", CheckInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewFileDriverWithOptions failed: %v", err)
+	}
+
+	driver.WriteLog("first")
+	// The log directory is replaced by a regular file, so the path can neither be checked nor reopened.
+	os.Rename(logDir, logDir+".old")
+	if err := os.WriteFile(logDir, nil, 0644); err != nil {
+		t.Fatalf("could not create file: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := driver.WriteLog("second"); err == nil {
+		t.Error("expected the reopen error to be reported")
+	}
+	if err := driver.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if lines := fileLines(t, filepath.Join(logDir+".old", "app.log")); !reflect.DeepEqual(lines, []string{"first", "second"}) {
+		t.Errorf("expected the entry in the open file, found %v", lines)
+	}
+	if err := driver.WriteLog("third"); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected os.ErrClosed after Close, got %v", err)
+	}
+}
@@ -0,0 +1,325 @@
+package test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"omnilogger"
+	"omnilogger/config"
+	drivers "omnilogger/pkg/drivers"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// readLines returns the lines of every file in dir.
+func readLines(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("could not read dir: %v", err)
+	}
+	var lines []string
+	for _, entry := range entries {
+		file, err := os.Open(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatalf("could not open %s: %v", entry.Name(), err)
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		file.Close()
+	}
+	return lines
+}
+
+func TestRotatingFileDriverNumberedBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	driver, err := drivers.NewRotatingFileDriver(path, drivers.RotationOptions{MaxSize: 100, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("NewRotatingFileDriver failed: %v", err)
+	}
+	defer driver.Close()
+
+	line := strings.Repeat("x", 39) // 40 bytes with the newline, two lines per file.
+	for i := 0; i < 7; i++ {
+		if err := driver.WriteLog(fmt.Sprintf("%d%s", i, line[1:])); err != nil {
+			t.Fatalf("WriteLog failed: %v", err)
+		}
+	}
+
+	expected := map[string]string{"app.log": "6", "app.log.1": "4", "app.log.2": "2"}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d files, got %d", len(expected), len(entries))
+	}
+	for name, first := range expected {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("could not read %s: %v", name, err)
+		}
+		if len(content) > 100 || !strings.HasPrefix(string(content), first) {
+			t.Errorf("%s: expected %d bytes or less starting with entry %s, got %q", name, 100, first, content)
+		}
+	}
+}
+
+func TestRotatingFileDriverTimestampedBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	driver, err := drivers.NewRotatingFileDriver(path, drivers.RotationOptions{MaxSize: 10, MaxBackups: 3, TimestampNames: true})
+	if err != nil {
+		t.Fatalf("NewRotatingFileDriver failed: %v", err)
+	}
+	defer driver.Close()
+
+	for i := 0; i < 6; i++ {
+		if err := driver.WriteLog(fmt.Sprintf("entry-%d", i)); err != nil {
+			t.Fatalf("WriteLog failed: %v", err)
+		}
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	if len(backups) != 3 {
+		t.Fatalf("expected 3 timestamped backups, got %v", backups)
+	}
+	content, _ := os.ReadFile(backups[0])
+	if string(content) != "entry-2\n" {
+		t.Errorf("expected the oldest kept backup to hold entry-2, got %q", content)
+	}
+}
+
+func TestRotatingFileDriverConcurrentLogging(t *testing.T) {
+	dir := t.TempDir()
+	driver, err := drivers.NewRotatingFileDriver(filepath.Join(dir, "app.log"), drivers.RotationOptions{MaxSize: 2048})
+	if err != nil {
+		t.Fatalf("NewRotatingFileDriver failed: %v", err)
+	}
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.INFO}, nil, driver)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				logger.With("goroutine", g).Info(fmt.Sprintf("entry %d", i))
+			}
+		}(g)
+	}
+	wg.Wait()
+	driver.Close()
+
+	lines := readLines(t, dir)
+	if len(lines) != 400 {
+		t.Fatalf("expected 400 entries across the files, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "{") || !strings.HasSuffix(line, "}") {
+			t.Fatalf("found a split entry: %q", line)
+		}
+	}
+	if files, _ := os.ReadDir(dir); len(files) < 2 {
+		t.Errorf("expected the file to have rotated, found %d files", len(files))
+	}
+}
+
+// fakeClock is a clock tests move forward by hand.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("could not open %s: %v", path, err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("could not read %s: %v", path, err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("could not read %s: %v", path, err)
+	}
+	return string(content)
+}
+
+func TestRotatingFileDriverDailyWithRetention(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+	driver, err := drivers.NewRotatingFileDriver(filepath.Join(dir, "app.log"), drivers.RotationOptions{
+		Interval:    drivers.RotateDaily,
+		NamePattern: "app-2006-01-02.log",
+		MaxAge:      36 * time.Hour,
+		Compress:    true,
+		Now:         clock.Now,
+		OnError:     func(err error) { t.Errorf("background error: %v", err) },
+	})
+	if err != nil {
+		t.Fatalf("NewRotatingFileDriver failed: %v", err)
+	}
+
+	for day := 1; day <= 5; day++ {
+		if err := driver.WriteLog(fmt.Sprintf("day %d", day)); err != nil {
+			t.Fatalf("WriteLog failed: %v", err)
+		}
+		clock.Advance(24 * time.Hour)
+	}
+	if err := driver.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	files, _ := os.ReadDir(dir)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	expected := []string{"app-2024-05-03.log.gz", "app-2024-05-04.log.gz", "app.log"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected files %v, got %v", expected, names)
+	}
+	if content := readGzip(t, filepath.Join(dir, "app-2024-05-04.log.gz")); content != "day 4\n" {
+		t.Errorf("expected the compressed backup to hold day 4, got %q", content)
+	}
+}
+
+func TestRotatingFileDriverHourly(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 10, 59, 0, 0, time.UTC)}
+	driver, err := drivers.NewRotatingFileDriver(filepath.Join(dir, "app.log"), drivers.RotationOptions{
+		Interval: drivers.RotateHourly,
+		Now:      clock.Now,
+	})
+	if err != nil {
+		t.Fatalf("NewRotatingFileDriver failed: %v", err)
+	}
+	defer driver.Close()
+
+	driver.WriteLog("10:59")
+	clock.Advance(30 * time.Second)
+	driver.WriteLog("10:59:30")
+	clock.Advance(30 * time.Second)
+	driver.WriteLog("11:00")
+
+	backup, _ := os.ReadFile(filepath.Join(dir, "app.log.1"))
+	current, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+	if string(backup) != "10:59\n10:59:30\n" || string(current) != "11:00\n" {
+		t.Errorf("expected a rollover at 11:00, got backup %q and file %q", backup, current)
+	}
+}
+
+func TestRotatingFileDriverPeriodWithoutEntries(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+	driver, err := drivers.NewRotatingFileDriver(filepath.Join(dir, "app.log"), drivers.RotationOptions{
+		Interval:    drivers.RotateDaily,
+		NamePattern: "app-2006-01-02.log",
+		Now:         clock.Now,
+	})
+	if err != nil {
+		t.Fatalf("NewRotatingFileDriver failed: %v", err)
+	}
+	defer driver.Close()
+
+	// Nothing is logged on May 1.
+	clock.Advance(24 * time.Hour)
+	driver.WriteLog("May 2")
+	clock.Advance(24 * time.Hour)
+	driver.WriteLog("May 3")
+
+	files, _ := os.ReadDir(dir)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	if expected := []string{"app-2024-05-02.log", "app.log"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected files %v, got %v", expected, names)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "app-2024-05-02.log")); string(content) != "May 2\n" {
+		t.Errorf("expected the May 2 backup to hold the May 2 entry, got %q", content)
+	}
+}
+
+func TestRotatingFileDriverCompressedNumberedBackups(t *testing.T) {
+	dir := t.TempDir()
+	driver, err := drivers.NewRotatingFileDriver(filepath.Join(dir, "app.log"), drivers.RotationOptions{
+		MaxSize:    10,
+		MaxBackups: 3,
+		Compress:   true,
+		OnError:    func(err error) { t.Errorf("background error: %v", err) },
+	})
+	if err != nil {
+		t.Fatalf("NewRotatingFileDriver failed: %v", err)
+	}
+
+	// Every entry rotates, usually while the previous backup is still being compressed.
+	for i := 0; i < 8; i++ {
+		if err := driver.WriteLog(fmt.Sprintf("entry-%d", i)); err != nil {
+			t.Fatalf("WriteLog failed: %v", err)
+		}
+	}
+	if err := driver.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	files, _ := os.ReadDir(dir)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	expected := []string{"app.log", "app.log.1.gz", "app.log.2.gz", "app.log.3.gz"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected files %v, got %v", expected, names)
+	}
+	for i, name := range expected[1:] {
+		if content := readGzip(t, filepath.Join(dir, name)); content != fmt.Sprintf("entry-%d\n", 6-i) {
+			t.Errorf("%s: expected entry-%d, got %q", name, 6-i, content)
+		}
+	}
+}
+
+func TestRotatingFileDriverStaysClosed(t *testing.T) {
+	dir := t.TempDir()
+	driver, err := drivers.NewRotatingFileDriver(filepath.Join(dir, "app.log"), drivers.RotationOptions{})
+	if err != nil {
+		t.Fatalf("NewRotatingFileDriver failed: %v", err)
+	}
+	driver.WriteLog("before")
+	if err := driver.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if err := driver.WriteLog("after"); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected os.ErrClosed from WriteLog, got %v", err)
+	}
+	if err := driver.Rotate(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected os.ErrClosed from Rotate, got %v", err)
+	}
+	if lines := readLines(t, dir); !reflect.DeepEqual(lines, []string{"before"}) {
+		t.Errorf("expected only the entry written before Close, got %v", lines)
+	}
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"omnilogger"
+	"omnilogger/config"
+	"omnilogger/model"
+	drivers "omnilogger/pkg/drivers"
+	"testing"
+	"time"
+)
+
+func TestSlogHandler(t *testing.T) {
+	driver := &RecordingDriver{}
+	cfg := config.Config{MinLevel: omnilogger.INFO}
+	logger := omnilogger.NewOmniLogger(cfg, &model.Context{TransactionID: "tx123"}, driver)
+
+	slogger := slog.New(omnilogger.NewSlogHandler(logger)).
+		With("service", "orders").
+		WithGroup("request").
+		With("method", "GET")
+
+	slogger.Debug("filtered out")
+	slogger.Warn("slow request", "duration_ms", 1200, slog.Group("client", "ip", "10.0.0.1"))
+
+	entries := driver.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Level != string(omnilogger.WARN) || entry.Message != "slow request" {
+		t.Errorf("expected a WARN 'slow request' entry, got %s %q", entry.Level, entry.Message)
+	}
+	if entry.Context.TransactionID != "tx123" || entry.Context.MetaData["service"] != "orders" {
+		t.Errorf("expected the logger context and top-level attrs, got %+v", entry.Context)
+	}
+
+	request, ok := entry.Context.MetaData["request"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a request group, got %v", entry.Context.MetaData)
+	}
+	client, _ := request["client"].(map[string]interface{})
+	if request["method"] != "GET" || request["duration_ms"] != int64(1200) || client["ip"] != "10.0.0.1" {
+		t.Errorf("expected grouped attrs, got %v", request)
+	}
+}
+
+func TestSlogHandlerKeepsRecordTime(t *testing.T) {
+	driver := &RecordingDriver{}
+	handler := omnilogger.NewSlogHandler(omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.INFO}, nil, driver))
+
+	recorded := time.Date(2024, 5, 1, 10, 4, 5, 0, time.UTC)
+	handler.Handle(context.Background(), slog.NewRecord(recorded, slog.LevelInfo, "replayed", 0))
+	handler.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "untimed", 0))
+
+	entries := driver.Entries()
+	if len(entries) != 2 || entries[0].Timestamp != "2024-05-01T10:04:05Z" {
+		t.Fatalf("expected the record time, got %+v", entries)
+	}
+	if logged, err := time.Parse(time.RFC3339, entries[1].Timestamp); err != nil || time.Since(logged) > time.Minute {
+		t.Errorf("expected the current time for a record without one, got %q", entries[1].Timestamp)
+	}
+}
+
+func TestSlogDriver(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+
+	cfg := config.Config{MinLevel: omnilogger.DEBUG}
+	logger := omnilogger.NewOmniLogger(cfg, &model.Context{UserID: "user456"}, drivers.NewSlogDriver(handler))
+	logger.With("order_id", 7).Warn("forwarded")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("failed to unmarshal slog output %q: %v", buf.String(), err)
+	}
+	if record["level"] != "WARN" || record["msg"] != "forwarded" {
+		t.Errorf("expected a WARN 'forwarded' record, got %v", record)
+	}
+	if record["user_id"] != "user456" || record["order_id"] != float64(7) {
+		t.Errorf("expected context attrs, got %v", record)
+	}
+}
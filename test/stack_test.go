@@ -0,0 +1,127 @@
+package test
+
+import (
+	"encoding/json"
+	"omnilogger"
+	"omnilogger/config"
+	"omnilogger/model"
+	drivers "omnilogger/pkg/drivers"
+	"strings"
+	"testing"
+)
+
+func logFromHelper(logger *omnilogger.OmniLogger) {
+	logger.Error("failed in helper")
+}
+
+func TestStackTraceForErrors(t *testing.T) {
+	driver := &RecordingDriver{}
+	cfg := config.Config{
+		MinLevel:   omnilogger.DEBUG,
+		StackTrace: config.StackTraceConfig{FilterRuntime: true},
+	}
+	logger := omnilogger.NewOmniLogger(cfg, nil, driver)
+
+	logger.Warn("no stack")
+	logFromHelper(logger)
+
+	entries := driver.Entries()
+	if len(entries[0].StackTrace) != 0 {
+		t.Errorf("expected no stack trace below ERROR, got %d frames", len(entries[0].StackTrace))
+	}
+
+	stack := entries[1].StackTrace
+	if len(stack) < 2 {
+		t.Fatalf("expected a multi-frame stack trace, got %v", stack)
+	}
+	if stack[0] != entries[1].Caller || !strings.HasSuffix(stack[0].Function, "logFromHelper") {
+		t.Errorf("expected the stack to start at the caller, got %s", stack[0])
+	}
+	if !strings.HasSuffix(stack[1].Function, "TestStackTraceForErrors") {
+		t.Errorf("expected the test function as second frame, got %s", stack[1])
+	}
+	for _, frame := range stack {
+		if strings.HasPrefix(frame.Function, "runtime.") {
+			t.Errorf("expected runtime frames to be filtered, got %s", frame)
+		}
+	}
+}
+
+func TestStackTraceLevelsAndDepth(t *testing.T) {
+	driver := &RecordingDriver{}
+	cfg := config.Config{
+		MinLevel: omnilogger.DEBUG,
+		StackTrace: config.StackTraceConfig{
+			MinLevel: omnilogger.WARN,
+			Levels:   map[config.LogLevel]bool{omnilogger.ERROR: false},
+			Depth:    1,
+		},
+	}
+	logger := omnilogger.NewOmniLogger(cfg, nil, driver)
+
+	logger.Warn("captured")
+	logger.Error("override")
+
+	entries := driver.Entries()
+	if len(entries[0].StackTrace) != 1 {
+		t.Errorf("expected a single WARN frame, got %d", len(entries[0].StackTrace))
+	}
+	if len(entries[1].StackTrace) != 0 {
+		t.Errorf("expected no ERROR stack trace, got %d frames", len(entries[1].StackTrace))
+	}
+}
+
+func TestGoroutineDumpOnFatal(t *testing.T) {
+	driver := &RecordingDriver{}
+	cfg := config.Config{
+		MinLevel:   omnilogger.DEBUG,
+		StackTrace: config.StackTraceConfig{GoroutineDump: true},
+	}
+	logger := omnilogger.NewOmniLogger(cfg, nil, driver)
+	logger.SetExitFunc(func(int) {})
+
+	logger.Error("no dump")
+	logger.Fatal("dump")
+
+	entries := driver.Entries()
+	if entries[0].Goroutines != "" {
+		t.Error("expected no goroutine dump for ERROR")
+	}
+	if !strings.Contains(entries[1].Goroutines, "goroutine ") {
+		t.Errorf("expected a goroutine dump for FATAL, got %q", entries[1].Goroutines)
+	}
+}
+
+func TestDriversRenderStackTrace(t *testing.T) {
+	messageData := model.MessageData{
+		Level:   string(omnilogger.ERROR),
+		Message: "boom",
+		Caller:  model.Frame{Function: "main.handler", File: "main.go", Line: 42},
+		StackTrace: []model.Frame{
+			{Function: "main.handler", File: "main.go", Line: 42},
+			{Function: "main.main", File: "main.go", Line: 10},
+		},
+	}
+
+	text, err := (&drivers.CLIDriver{}).FormatLog(messageData)
+	if err != nil {
+		t.Fatalf("FormatLog failed: %v", err)
+	}
+	if !strings.HasSuffix(text, "\nstack trace:\nmain.handler\n\tmain.go:42\nmain.main\n\tmain.go:10") {
+		t.Errorf("expected a multi-line stack trace, got %q", text)
+	}
+
+	formatted, err := (&drivers.JsonCliDriver{}).FormatLog(messageData)
+	if err != nil {
+		t.Fatalf("FormatLog failed: %v", err)
+	}
+	var logEntry struct {
+		StackTrace []model.Frame `json:"stack"`
+	}
+	if err := json.Unmarshal([]byte(formatted), &logEntry); err != nil {
+		t.Fatalf("failed to unmarshal log entry: %v", err)
+	}
+	if len(logEntry.StackTrace) != 2 || logEntry.StackTrace[1].Function != "main.main" || logEntry.StackTrace[1].Line != 10 {
+		t.Errorf("expected a stack trace array, got %+v", logEntry.StackTrace)
+	}
+}
@@ -0,0 +1,206 @@
+package test
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"omnilogger"
+	"omnilogger/config"
+	"omnilogger/model"
+	drivers "omnilogger/pkg/drivers"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func syslogEntry() model.MessageData {
+	return model.MessageData{
+		Level:     "WARN",
+		Message:   "order saved",
+		Timestamp: "2024-05-01T10:04:05Z",
+		Error:     errors.New("retry"),
+		Context: &model.Context{
+			UserID:   "user456",
+			MetaData: map[string]interface{}{"order_id": 7, "note": `say "hi" [ok]`, "http": map[string]interface{}{"method": "POST"}},
+		},
+	}
+}
+
+func listenUDP(t *testing.T) net.PacketConn {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readPacket(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("could not read: %v", err)
+	}
+	return string(buf[:n])
+}
+
+func TestSyslogDriverRFC5424(t *testing.T) {
+	listener := listenUDP(t)
+	driver, err := drivers.NewSyslogDriver(drivers.SyslogOptions{
+		Network:  "udp",
+		Address:  listener.LocalAddr().String(),
+		Facility: drivers.FacilityLocal0,
+		AppName:  "orders",
+		Hostname: "web-1",
+	})
+	if err != nil {
+		t.Fatalf("NewSyslogDriver failed: %v", err)
+	}
+	defer driver.Close()
+
+	formatted, err := driver.FormatLog(syslogEntry())
+	if err != nil {
+		t.Fatalf("FormatLog failed: %v", err)
+	}
+	expected := "<132>1 2024-05-01T10:04:05Z web-1 orders " + strconv.Itoa(os.Getpid()) + ` - ` +
+		`[omnilogger@32473 user_id="user456" http.method="POST" note="say \"hi\" [ok\]" order_id="7" error="retry"] order saved`
+	if formatted != expected {
+		t.Errorf("expected %s\ngot      %s", expected, formatted)
+	}
+
+	if err := driver.WriteLog(formatted); err != nil {
+		t.Fatalf("WriteLog failed: %v", err)
+	}
+	if received := readPacket(t, listener); received != expected {
+		t.Errorf("expected the datagram %q, got %q", expected, received)
+	}
+}
+
+func TestSyslogDriverRFC3164(t *testing.T) {
+	listener := listenUDP(t)
+	driver, err := drivers.NewSyslogDriver(drivers.SyslogOptions{
+		Network:    "udp",
+		Address:    listener.LocalAddr().String(),
+		Format:     drivers.SyslogRFC3164,
+		AppName:    "orders",
+		Hostname:   "web-1",
+		Severities: map[config.LogLevel]drivers.SyslogSeverity{omnilogger.WARN: drivers.SeverityNotice},
+	})
+	if err != nil {
+		t.Fatalf("NewSyslogDriver failed: %v", err)
+	}
+	defer driver.Close()
+
+	formatted, _ := driver.FormatLog(syslogEntry())
+	expected := "<13>May  1 10:04:05 web-1 orders[" + strconv.Itoa(os.Getpid()) + `]: order saved user_id=user456 http.method=POST note="say \"hi\" [ok]" order_id=7 error=retry`
+	if formatted != expected {
+		t.Errorf("expected %s\ngot      %s", expected, formatted)
+	}
+}
+
+func TestSyslogDriverSeverities(t *testing.T) {
+	listener := listenUDP(t)
+	driver, err := drivers.NewSyslogDriver(drivers.SyslogOptions{Network: "udp", Address: listener.LocalAddr().String()})
+	if err != nil {
+		t.Fatalf("NewSyslogDriver failed: %v", err)
+	}
+	defer driver.Close()
+	config.RegisterLevel("TRACE", 5)
+
+	expected := map[string]string{"TRACE": "<15>", "DEBUG": "<15>", "INFO": "<14>", "WARN": "<12>", "ERROR": "<11>", "FATAL": "<10>"}
+	for level, priority := range expected {
+		formatted, _ := driver.FormatLog(model.MessageData{Level: level, Message: "m"})
+		if !strings.HasPrefix(formatted, priority) {
+			t.Errorf("%s: expected priority %s, got %s", level, priority, formatted)
+		}
+	}
+}
+
+func TestSyslogDriverTCPOctetCounting(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer listener.Close()
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		var frames []string
+		for len(frames) < 2 {
+			length, err := reader.ReadString(' ')
+			if err != nil {
+				break
+			}
+			n, _ := strconv.Atoi(strings.TrimSuffix(length, " "))
+			frame := make([]byte, n)
+			if _, err := io.ReadFull(reader, frame); err != nil {
+				break
+			}
+			frames = append(frames, string(frame))
+		}
+		received <- frames
+	}()
+
+	driver, err := drivers.NewSyslogDriver(drivers.SyslogOptions{Network: "tcp", Address: listener.Addr().String(), AppName: "orders"})
+	if err != nil {
+		t.Fatalf("NewSyslogDriver failed: %v", err)
+	}
+	defer driver.Close()
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.INFO}, nil, driver)
+
+	logger.Info("first line\nwith a newline")
+	logger.With("order_id", 7).Error("second")
+
+	select {
+	case frames := <-received:
+		if len(frames) != 2 || !strings.HasSuffix(frames[0], "- first line\nwith a newline") ||
+			!strings.HasSuffix(frames[1], `[omnilogger@32473 order_id="7"] second`) {
+			t.Errorf("unexpected frames: %q", frames)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected two frames")
+	}
+}
+
+func TestSyslogDriverUnixgram(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix datagram sockets are not available on Windows")
+	}
+	dir, err := os.MkdirTemp("", "syslog")
+	if err != nil {
+		t.Fatalf("could not create dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log")
+	listener, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer listener.Close()
+
+	driver, err := drivers.NewSyslogDriver(drivers.SyslogOptions{Address: path, Format: drivers.SyslogRFC3164, AppName: "orders"})
+	if err != nil {
+		t.Fatalf("NewSyslogDriver failed: %v", err)
+	}
+	defer driver.Close()
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.INFO}, nil, driver)
+	logger.Info("local")
+
+	received := readPacket(t, listener)
+	if !strings.HasPrefix(received, "<14>") || !strings.HasSuffix(received, " orders["+strconv.Itoa(os.Getpid())+"]: local") {
+		t.Errorf("unexpected datagram: %q", received)
+	}
+}
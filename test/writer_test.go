@@ -0,0 +1,54 @@
+package test
+
+import (
+	"fmt"
+	"log"
+	"omnilogger"
+	"omnilogger/config"
+	"testing"
+)
+
+func TestWriterSplitsLines(t *testing.T) {
+	driver := &RecordingDriver{}
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.DEBUG}, nil, driver)
+
+	writer := omnilogger.NewWriterWithOptions(logger, omnilogger.INFO, omnilogger.WriterOptions{
+		Prefix:     "db: ",
+		ParseLevel: true,
+	})
+	fmt.Fprint(writer, "db: connected\ndb: [warn] slow ")
+	fmt.Fprint(writer, "query\r\nERROR: pool exhausted\npartial")
+
+	entries := driver.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries before flushing, got %d", len(entries))
+	}
+	writer.Flush()
+	entries = driver.Entries()
+
+	expected := []struct{ level, message string }{
+		{"INFO", "connected"},
+		{"WARN", "slow query"},
+		{"ERROR", "pool exhausted"},
+		{"INFO", "partial"},
+	}
+	for i, want := range expected {
+		if entries[i].Level != want.level || entries[i].Message != want.message {
+			t.Errorf("entry %d: expected %s %q, got %s %q", i, want.level, want.message, entries[i].Level, entries[i].Message)
+		}
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	driver := &RecordingDriver{}
+	logger := omnilogger.NewOmniLogger(config.Config{MinLevel: omnilogger.DEBUG}, nil, driver)
+
+	restore := omnilogger.RedirectStdLog(logger, omnilogger.WARN)
+	log.Printf("third-party says %s", "hello")
+	restore()
+
+	entries := driver.Entries()
+	if len(entries) != 1 || entries[0].Level != "WARN" || entries[0].Message != "third-party says hello" {
+		t.Errorf("expected a single WARN entry from the log package, got %+v", entries)
+	}
+}
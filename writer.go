@@ -0,0 +1,147 @@
+package omnilogger
+
+import (
+	"bytes"
+	"log"
+	"omnilogger/config"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WriterOptions controls how a Writer turns written bytes into log entries.
+type WriterOptions struct {
+	Prefix     string // Stripped from the start of every line when present.
+	ParseLevel bool   // Log lines starting with "[LEVEL]" or "LEVEL:" at that level instead of the default one.
+}
+
+// Writer is an io.Writer that logs every written line through an OmniLogger.
+// Partial lines are buffered until their newline arrives or Flush is called.
+// A Writer never ends the process, even for lines logged at a terminal level.
+type Writer struct {
+	logger  *OmniLogger
+	level   config.LogLevel
+	options WriterOptions
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// NewWriter returns a Writer that logs each line at the given level.
+func NewWriter(logger *OmniLogger, level config.LogLevel) *Writer {
+	return NewWriterWithOptions(logger, level, WriterOptions{})
+}
+
+// NewWriterWithOptions returns a Writer that logs each line at the given level using options.
+func NewWriterWithOptions(logger *OmniLogger, level config.LogLevel, options WriterOptions) *Writer {
+	return &Writer{logger: logger, level: level, options: options}
+}
+
+// NewStdLogger returns a *log.Logger for packages that expect one, writing through logger at level.
+func NewStdLogger(logger *OmniLogger, level config.LogLevel) *log.Logger {
+	return log.New(NewWriterWithOptions(logger, level, WriterOptions{ParseLevel: true}), "", 0)
+}
+
+// RedirectStdLog sends the output of the global log package to logger at level.
+// The returned function restores the previous output, prefix and flags.
+func RedirectStdLog(logger *OmniLogger, level config.LogLevel) (restore func()) {
+	output, prefix, flags := log.Writer(), log.Prefix(), log.Flags()
+
+	log.SetOutput(NewWriterWithOptions(logger, level, WriterOptions{ParseLevel: true}))
+	log.SetPrefix("")
+	log.SetFlags(0) // OmniLogger adds its own timestamp.
+
+	return func() {
+		log.SetOutput(output)
+		log.SetPrefix(prefix)
+		log.SetFlags(flags)
+	}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	var pc uintptr
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if pc == 0 {
+			pc = writerCallerPC()
+		}
+		w.writeLine(string(w.buf[:i]), pc)
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) == 0 {
+		w.buf = nil // Release the backing array once everything is written.
+	}
+	return len(p), nil
+}
+
+// Flush logs a buffered partial line, if any.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.writeLine(string(w.buf), callerPC(1))
+		w.buf = nil
+	}
+	return nil
+}
+
+func (w *Writer) writeLine(line string, pc uintptr) {
+	line = strings.TrimSuffix(line, "\r")
+	line = strings.TrimPrefix(line, w.options.Prefix)
+
+	level := w.level
+	if w.options.ParseLevel {
+		level, line = parseLevelPrefix(line, level)
+	}
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	w.logger.logWritter(level, line, pc, time.Time{})
+}
+
+// writerCallerPC returns the code that called Writer.Write, looking past the standard log
+// package so output redirected with RedirectStdLog points at the log.Printf call site.
+func writerCallerPC() uintptr {
+	var pcs [16]uintptr
+	n := runtime.Callers(3, pcs[:]) // Skip runtime.Callers, writerCallerPC and Writer.Write.
+	for _, pc := range pcs[:n] {
+		if !strings.HasPrefix(frameForPC(pc).Function, "log.") {
+			return pc
+		}
+	}
+	return 0
+}
+
+// parseLevelPrefix detects a registered level written as "[LEVEL] message" or "LEVEL: message".
+func parseLevelPrefix(line string, fallback config.LogLevel) (config.LogLevel, string) {
+	var name, rest string
+	switch {
+	case strings.HasPrefix(line, "["):
+		end := strings.IndexByte(line, ']')
+		if end < 0 {
+			return fallback, line
+		}
+		name, rest = line[1:end], line[end+1:]
+	default:
+		end := strings.IndexByte(line, ':')
+		if end < 0 {
+			return fallback, line
+		}
+		name, rest = line[:end], line[end+1:]
+	}
+
+	level, err := config.ParseLevel(name)
+	if err != nil {
+		return fallback, line
+	}
+	return level, strings.TrimLeft(rest, " ")
+}